@@ -62,9 +62,21 @@ type Attachment struct {
 	FileName   string    `bson:"fileName" json:"file_name"`
 	FileURL    string    `bson:"fileUrl" json:"file_url"`
 	FileType   string    `bson:"fileType" json:"file_type"`
+	Checksum   string    `bson:"checksum,omitempty" json:"checksum,omitempty"`
 	UploadedAt time.Time `bson:"uploadedAt" json:"uploaded_at"`
 }
 
+// Achievement reference statuses
+const (
+	StatusDraft         = "draft"
+	StatusSubmitted     = "submitted"
+	StatusVerified      = "verified"
+	StatusRejected      = "rejected"
+	StatusNeedsRevision = "needs_revision"
+	StatusWithdrawn     = "withdrawn"
+	StatusRevoked       = "revoked"
+)
+
 type AchievementReference struct {
 	ID                 string     `json:"id"`
 	StudentID          string     `json:"student_id"`
@@ -74,9 +86,26 @@ type AchievementReference struct {
 	VerifiedAt         *time.Time `json:"verified_at"`
 	VerifiedBy         *string    `json:"verified_by"`
 	RejectionNote      *string    `json:"rejection_note"`
+	ApprovalStep       int        `json:"approval_step"`
+	TeamPointRule      string     `json:"team_point_rule"`
+	DuplicateOf        *string    `json:"duplicate_of"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy          *string    `json:"revoked_by,omitempty"`
+	RevocationReason   *string    `json:"revocation_reason,omitempty"`
+	DeletedAt          *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy          *string    `json:"deleted_by,omitempty"`
+	ExpiredAt          *time.Time `json:"expired_at,omitempty"`
+	RenewalOf          *string    `json:"renewal_of,omitempty"`
+	PeriodID           *string    `json:"period_id,omitempty"`
+	ApprovalChainID    *string    `json:"approval_chain_id,omitempty"`
+	ApprovalSteps      []ApprovalStep `json:"approval_steps,omitempty"`
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 	Achievement        *Achievement `json:"achievement,omitempty"`
+	Comments           []*AchievementComment `json:"comments,omitempty"`
+	Approvals          []*AchievementApproval `json:"approvals,omitempty"`
+	Members            []*AchievementMember `json:"members,omitempty"`
+	DuplicateCandidates []*DuplicateCandidate `json:"duplicate_candidates,omitempty"`
 }
 
 type CreateAchievementRequest struct {
@@ -86,6 +115,9 @@ type CreateAchievementRequest struct {
 	Details         AchievementDetails `json:"details"`
 	Tags            []string           `json:"tags"`
 	Points          int                `json:"points"`
+	Attachments     []Attachment       `json:"attachments,omitempty"`
+	Members         []AchievementMemberInput `json:"members,omitempty"`
+	TeamPointRule   string             `json:"team_point_rule,omitempty"`
 }
 
 type UpdateAchievementRequest struct {
@@ -94,9 +126,68 @@ type UpdateAchievementRequest struct {
 	Details     AchievementDetails `json:"details,omitempty"`
 	Tags        []string           `json:"tags,omitempty"`
 	Points      int                `json:"points,omitempty"`
+	// Members replaces the team when present, an empty list removes it
+	Members       []AchievementMemberInput `json:"members,omitempty"`
+	TeamPointRule string                   `json:"team_point_rule,omitempty"`
 }
 
 type VerifyAchievementRequest struct {
-	Action string `json:"action"` // "verify" or "reject"
+	Action string `json:"action"` // "verify", "reject" or "request_revision"
 	Note   string `json:"note,omitempty"`
 }
+
+// RevokeAchievementRequest withdraws a verification, for example when fraud
+// is discovered later. The reason is required.
+type RevokeAchievementRequest struct {
+	Reason string `json:"reason"`
+}
+
+// AchievementStatusChange is one entry in an achievement's status history
+type AchievementStatusChange struct {
+	ID               string    `json:"id"`
+	AchievementRefID string    `json:"achievement_id"`
+	FromStatus       string    `json:"from_status"`
+	ToStatus         string    `json:"to_status"`
+	Action           string    `json:"action"`
+	ActorID          *string   `json:"actor_id"`
+	ActorName        *string   `json:"actor_name"`
+	Note             *string   `json:"note"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// StatusTransition is everything written in one transaction when an
+// achievement changes status or completes an intermediate approval step.
+// The reference only moves while it is still in FromStatus, and at FromStep
+// when the status stays the same. A submission also stores the approval
+// chain it is decided by.
+type StatusTransition struct {
+	AchievementRefID string
+	FromStatus       string
+	ToStatus         string
+	FromStep         int
+	ApprovalChainID  *string
+	ApprovalSteps    []ApprovalStep
+	ReviewerID       *string
+	Note             *string
+	Change           *AchievementStatusChange
+	Approval         *AchievementApproval
+	Comment          *AchievementComment
+}
+
+type BulkVerifyAchievementRequest struct {
+	IDs    []string `json:"ids"`
+	Action string   `json:"action"`
+	Note   string   `json:"note,omitempty"`
+}
+
+type BulkVerifyItemResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+type BulkVerifyAchievementResponse struct {
+	Succeeded int                    `json:"succeeded"`
+	Failed    int                    `json:"failed"`
+	Results   []BulkVerifyItemResult `json:"results"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// Approval actions recorded for each review decision
+const (
+	ApprovalActionApprove         = "approve"
+	ApprovalActionReject          = "reject"
+	ApprovalActionRequestRevision = "request_revision"
+)
+
+// ApprovalChain lists the steps an achievement must pass before it becomes
+// verified. A chain applies to an achievement type, a competition level,
+// both, or (when both are empty) to every achievement.
+type ApprovalChain struct {
+	ID               string         `json:"id"`
+	Name             string         `json:"name"`
+	AchievementType  *string        `json:"achievement_type"`
+	CompetitionLevel *string        `json:"competition_level"`
+	Steps            []ApprovalStep `json:"steps"`
+	CreatedAt        time.Time      `json:"created_at"`
+}
+
+type ApprovalStep struct {
+	ID           string   `json:"id,omitempty"`
+	StepOrder    int      `json:"step_order"`
+	Name         string   `json:"name"`
+	AllowedRoles []string `json:"allowed_roles"`
+}
+
+type AchievementApproval struct {
+	ID               string     `json:"id"`
+	AchievementRefID string     `json:"achievement_id"`
+	StepOrder        int        `json:"step_order"`
+	StepName         string     `json:"step_name"`
+	ApproverID       string     `json:"approver_id"`
+	ApproverName     string     `json:"approver_name"`
+	Action           string     `json:"action"`
+	Note             *string    `json:"note"`
+	SubmittedAt      *time.Time `json:"submitted_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+type CreateApprovalChainRequest struct {
+	Name             string         `json:"name"`
+	AchievementType  *string        `json:"achievement_type,omitempty"`
+	CompetitionLevel *string        `json:"competition_level,omitempty"`
+	Steps            []ApprovalStep `json:"steps"`
+}
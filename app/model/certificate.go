@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Certificate holds what is printed on a verified achievement's certificate
+type Certificate struct {
+	AchievementID   string
+	Institution     string
+	StudentName     string
+	StudentNumber   string
+	ProgramStudy    string
+	Title           string
+	Description     SKPIText
+	VerifiedAt      time.Time
+	VerifiedBy      string
+	VerificationURL string
+}
+
+// PublicVerification is the minimal, unauthenticated view of a certificate
+// shown to whoever scans its QR code
+type PublicVerification struct {
+	Institution      string     `json:"institution"`
+	StudentName      string     `json:"student_name"`
+	ProgramStudy     string     `json:"program_study"`
+	Title            string     `json:"title"`
+	AchievementType  string     `json:"achievement_type"`
+	CompetitionLevel string     `json:"competition_level,omitempty"`
+	EventDate        *time.Time `json:"event_date,omitempty"`
+	VerifiedAt       time.Time  `json:"verified_at"`
+	VerifiedBy       string     `json:"verified_by"`
+	ValidUntil       *time.Time `json:"valid_until,omitempty"`
+	Expired          bool       `json:"expired"`
+}
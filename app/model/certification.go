@@ -0,0 +1,7 @@
+package model
+
+// SetRenewalOfRequest links a certification to the one it renews. A nil
+// RenewalOf removes the link.
+type SetRenewalOfRequest struct {
+	RenewalOf *string `json:"renewal_of"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Comment kinds
+const (
+	CommentKindComment         = "comment"
+	CommentKindRejection       = "rejection"
+	CommentKindRevisionRequest = "revision_request"
+	CommentKindRevocation      = "revocation"
+)
+
+type AchievementComment struct {
+	ID               string     `json:"id"`
+	AchievementRefID string     `json:"achievement_id"`
+	ParentID         *string    `json:"parent_id"`
+	AuthorID         string     `json:"author_id"`
+	AuthorName       string     `json:"author_name"`
+	AuthorRole       string     `json:"author_role"`
+	Kind             string     `json:"kind"`
+	Body             string     `json:"body"`
+	Revision         *int       `json:"revision"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+}
+
+type CreateCommentRequest struct {
+	Body     string  `json:"body"`
+	ParentID *string `json:"parent_id,omitempty"`
+	Revision *int    `json:"revision,omitempty"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body"`
+}
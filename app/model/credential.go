@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// IssuerKey is the Ed25519 key pair credentials are signed with
+type IssuerKey struct {
+	ID         int
+	PublicKey  []byte
+	PrivateKey []byte
+	CreatedAt  time.Time
+}
+
+// AchievementCredential is an Open Badges 3.0 credential issued for a
+// verified achievement. StatusIndex is its entry in the revocation status
+// list.
+type AchievementCredential struct {
+	ID               string                 `json:"id"`
+	AchievementRefID string                 `json:"achievement_id"`
+	StatusIndex      int                    `json:"status_index"`
+	Credential       map[string]interface{} `json:"credential"`
+	IssuedAt         time.Time              `json:"issued_at"`
+	RevokedAt        *time.Time             `json:"revoked_at,omitempty"`
+}
+
+// CredentialCheck is the outcome of one step of verifying a credential
+type CredentialCheck struct {
+	Check  string `json:"check"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+type CredentialVerificationResult struct {
+	Verified bool              `json:"verified"`
+	Checks   []CredentialCheck `json:"checks"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// DuplicateCandidate is an existing achievement that looks like the same
+// achievement reported again
+type DuplicateCandidate struct {
+	AchievementID string    `json:"achievement_id"`
+	MongoID       string    `json:"-"`
+	StudentID     string    `json:"student_id"`
+	Title         string    `json:"title"`
+	Status        string    `json:"status"`
+	Score         float64   `json:"score"`
+	Reasons       []string  `json:"reasons"`
+	DetectedAt    time.Time `json:"detected_at"`
+}
+
+type SetDuplicateOfRequest struct {
+	DuplicateOf *string `json:"duplicate_of"`
+}
@@ -0,0 +1,20 @@
+package model
+
+// Report export formats. An empty format means the usual JSON response.
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+	ExportFormatPDF  = "pdf"
+)
+
+// Export column types, used to write typed cells in spreadsheets
+const (
+	ExportColumnText   = "text"
+	ExportColumnNumber = "number"
+	ExportColumnDate   = "date"
+)
+
+type ExportColumn struct {
+	Header string
+	Type   string
+}
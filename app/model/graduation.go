@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// GraduationRequirement is the minimum of verified achievement points (SKKM)
+// a student needs before graduating. Cohort is empty for the default of the
+// program study; a requirement for the student's own cohort takes
+// precedence.
+type GraduationRequirement struct {
+	ID           string                `json:"id"`
+	ProgramStudy string                `json:"program_study"`
+	Cohort       string                `json:"cohort"`
+	MinPoints    int                   `json:"min_points"`
+	Categories   []RequirementCategory `json:"categories"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+}
+
+// RequirementCategory is the minimum of points within one SKKM category
+type RequirementCategory struct {
+	Category  string `json:"category"`
+	MinPoints int    `json:"min_points"`
+}
+
+// GraduationRequirementRequest creates a requirement or replaces all of its
+// fields
+type GraduationRequirementRequest struct {
+	ProgramStudy string                `json:"program_study"`
+	Cohort       string                `json:"cohort"`
+	MinPoints    int                   `json:"min_points"`
+	Categories   []RequirementCategory `json:"categories"`
+}
+
+// CategoryProgress is a student's points within one category. MinPoints is
+// 0 for categories the requirement sets no minimum for.
+type CategoryProgress struct {
+	Category  string  `json:"category"`
+	Points    float64 `json:"points"`
+	MinPoints int     `json:"min_points"`
+	Remaining float64 `json:"remaining"`
+	Met       bool    `json:"met"`
+}
+
+// GraduationProgress is where a student stands against their requirement.
+// Requirement is nil when none is configured for the student's program
+// study, and Met is then false.
+type GraduationProgress struct {
+	StudentID        string                 `json:"student_id"`
+	StudentNumber    string                 `json:"student_number"`
+	FullName         string                 `json:"full_name"`
+	ProgramStudy     string                 `json:"program_study"`
+	Cohort           string                 `json:"cohort"`
+	Requirement      *GraduationRequirement `json:"requirement"`
+	TotalPoints      float64                `json:"total_points"`
+	Remaining        float64                `json:"remaining"`
+	Met              bool                   `json:"met"`
+	AchievementCount int                    `json:"achievement_count"`
+	Categories       []CategoryProgress     `json:"categories"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// Leaderboard ranking criteria
+const (
+	LeaderboardByPoints = "points"
+	LeaderboardByCount  = "count"
+)
+
+// LeaderboardFilter selects the verified achievements a ranking is built
+// from. Empty fields are not filtered on. Period is the code of an academic
+// period such as "2024-1" (ganjil 2024/2025); PeriodID is resolved from it.
+type LeaderboardFilter struct {
+	By              string `json:"by"`
+	ProgramStudy    string `json:"program_study,omitempty"`
+	Cohort          string `json:"cohort,omitempty"`
+	Period          string `json:"period,omitempty"`
+	PeriodID        string `json:"-"`
+	AchievementType string `json:"achievement_type,omitempty"`
+	IncludeExpired  bool   `json:"include_expired,omitempty"`
+}
+
+type LeaderboardEntry struct {
+	Rank             int        `json:"rank"`
+	StudentID        string     `json:"student_id"`
+	StudentNumber    string     `json:"student_number"`
+	FullName         string     `json:"full_name"`
+	ProgramStudy     *string    `json:"program_study"`
+	AcademicYear     *string    `json:"academic_year"`
+	AdvisorID        *string    `json:"-"`
+	TotalPoints      float64    `json:"total_points"`
+	AchievementCount int        `json:"achievement_count"`
+	LastVerifiedAt   *time.Time `json:"last_verified_at"`
+}
+
+type Leaderboard struct {
+	Filter      LeaderboardFilter  `json:"filter"`
+	TotalRanked int                `json:"total_ranked"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	Entries     []LeaderboardEntry `json:"entries"`
+}
+
+// CreditedAchievementRow is a verified achievement credited to one student,
+// the owner or a team member who did not decline
+type CreditedAchievementRow struct {
+	AchievementRefID   string
+	MongoAchievementID string
+	TeamPointRule      string
+	TeamSize           int
+	VerifiedAt         *time.Time
+	StudentID          string
+	StudentNumber      string
+	FullName           string
+	ProgramStudy       *string
+	AcademicYear       *string
+	AdvisorID          *string
+}
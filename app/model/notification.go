@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Notification types
+const (
+	NotificationReviewReminder   = "review_reminder"
+	NotificationReviewEscalation = "review_escalation"
+	NotificationRevoked          = "achievement_revoked"
+	NotificationExpiring         = "certification_expiring"
+	NotificationExpired          = "certification_expired"
+)
+
+type Notification struct {
+	ID               string     `json:"id"`
+	UserID           string     `json:"user_id"`
+	Type             string     `json:"type"`
+	Title            string     `json:"title"`
+	Message          string     `json:"message"`
+	AchievementRefID *string    `json:"achievement_id"`
+	ReadAt           *time.Time `json:"read_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Outbox operations applied to MongoDB after the PostgreSQL side committed
+const (
+	OutboxCreateDocument = "create_document"
+	OutboxDeleteDocument = "delete_document"
+)
+
+// Outbox event statuses
+const (
+	OutboxStatusPending     = "pending"
+	OutboxStatusDone        = "done"
+	OutboxStatusFailed      = "failed"
+	OutboxStatusCompensated = "compensated"
+)
+
+// OutboxEvent is a MongoDB change recorded in the same PostgreSQL
+// transaction as the reference it belongs to
+type OutboxEvent struct {
+	ID                 string          `json:"id"`
+	AchievementRefID   string          `json:"achievement_id"`
+	MongoAchievementID string          `json:"mongo_achievement_id"`
+	Operation          string          `json:"operation"`
+	Payload            json.RawMessage `json:"payload,omitempty"`
+	Status             string          `json:"status"`
+	Attempts           int             `json:"attempts"`
+	LastError          *string         `json:"last_error"`
+	NextAttemptAt      time.Time       `json:"next_attempt_at"`
+	CreatedAt          time.Time       `json:"created_at"`
+	ProcessedAt        *time.Time      `json:"processed_at"`
+}
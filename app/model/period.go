@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// AcademicPeriod is a semester with its date range. Code is the sortable
+// key used in filters, such as 2025-1 for Ganjil 2025/2026 and 2025-2 for
+// Genap 2025/2026. At most one period is active.
+type AcademicPeriod struct {
+	ID        string    `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AcademicPeriodRequest creates a period or replaces all of its fields.
+// Dates use YYYY-MM-DD and both ends are included.
+type AcademicPeriodRequest struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	IsActive  bool   `json:"is_active"`
+}
+
+// ReferencePeriodKey is what is needed to place a reference in a period
+// besides its document's event date
+type ReferencePeriodKey struct {
+	ID                 string
+	MongoAchievementID string
+	CreatedAt          time.Time
+	PeriodID           *string
+}
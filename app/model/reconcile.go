@@ -0,0 +1,50 @@
+package model
+
+// Inconsistencies found between the PostgreSQL references and the MongoDB
+// achievement documents
+const (
+	IssueOrphanDocument    = "orphan_document"
+	IssueDanglingReference = "dangling_reference"
+	IssueStudentMismatch   = "student_mismatch"
+)
+
+// Repairs applied for an inconsistency
+const (
+	RepairRecreateReference = "recreate_reference"
+	RepairArchiveDocument   = "archive_document"
+	RepairRestoreDocument   = "restore_document"
+	RepairFixStudentID      = "fix_student_id"
+	RepairNone              = "none"
+)
+
+type ReconcileIssue struct {
+	Kind               string  `json:"kind"`
+	AchievementRefID   *string `json:"achievement_id"`
+	MongoAchievementID string  `json:"mongo_achievement_id"`
+	StudentID          *string `json:"student_id"`
+	DocumentStudentID  *string `json:"document_student_id"`
+	Repair             string  `json:"repair"`
+	Detail             string  `json:"detail,omitempty"`
+	Applied            bool    `json:"applied"`
+	Error              string  `json:"error,omitempty"`
+}
+
+type ReconcileReport struct {
+	DryRun            bool              `json:"dry_run"`
+	ScannedReferences int               `json:"scanned_references"`
+	ScannedDocuments  int               `json:"scanned_documents"`
+	Issues            []*ReconcileIssue `json:"issues"`
+}
+
+// ReferenceKey identifies a reference and the document it points to
+type ReferenceKey struct {
+	ID                 string
+	StudentID          string
+	MongoAchievementID string
+}
+
+// DocumentKey identifies an achievement document and its owner
+type DocumentKey struct {
+	ID        string
+	StudentID string
+}
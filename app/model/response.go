@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Response struct {
 	Status  string      `json:"status"`
 	Message string      `json:"message,omitempty"`
@@ -18,3 +20,29 @@ type Pagination struct {
 	TotalItems int64 `json:"total_items"`
 	TotalPages int   `json:"total_pages"`
 }
+
+// CursorPaginatedResponse is the keyset alternative to PaginatedResponse
+type CursorPaginatedResponse struct {
+	Status string           `json:"status"`
+	Data   interface{}      `json:"data"`
+	Meta   CursorPagination `json:"meta"`
+}
+
+// CursorPagination describes a page of a keyset listing. Next and Prev are
+// links to the neighbouring pages, TotalItems is only set when requested.
+type CursorPagination struct {
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+	Next       *string `json:"next"`
+	Prev       *string `json:"prev"`
+	TotalItems *int64  `json:"total_items,omitempty"`
+}
+
+// Cursor is a position in a listing ordered by (created_at, id), newest
+// first. Backward cursors page towards newer rows.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+	Backward  bool      `json:"b,omitempty"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// DeletedAchievement is a soft deleted achievement that can still be
+// restored. PurgeAt is when the retention job removes it for good.
+type DeletedAchievement struct {
+	*AchievementReference
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// PurgeResult reports how many soft deleted achievements a purge removed
+type PurgeResult struct {
+	Purged int `json:"purged"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AchievementRevision is an immutable snapshot of an achievement document
+// taken every time the document is created or edited.
+type AchievementRevision struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AchievementID string             `bson:"achievementId" json:"achievement_id"`
+	Revision      int                `bson:"revision" json:"revision"`
+	AuthorID      string             `bson:"authorId" json:"author_id"`
+	Status        string             `bson:"status" json:"status"`
+	Note          string             `bson:"note,omitempty" json:"note,omitempty"`
+	Snapshot      Achievement        `bson:"snapshot" json:"snapshot"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"created_at"`
+}
+
+type FieldChange struct {
+	Field    string      `json:"field"`
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+}
+
+type RevisionDiff struct {
+	AchievementID string        `json:"achievement_id"`
+	From          int           `json:"from"`
+	To            int           `json:"to"`
+	Changes       []FieldChange `json:"changes"`
+}
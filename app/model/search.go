@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// AchievementSearchFilter combines a free text query with structured filters.
+// Empty fields are not filtered on.
+type AchievementSearchFilter struct {
+	Query        string
+	Type         string
+	Level        string
+	Medal        string
+	Tags         []string
+	EventFrom    *time.Time
+	EventTo      *time.Time
+	MinPoints    *int
+	MaxPoints    *int
+	Status       string
+	ProgramStudy string
+}
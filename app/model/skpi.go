@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// SKPIText is a phrase in Indonesian and English, as the diploma supplement
+// is issued in both languages
+type SKPIText struct {
+	ID string `json:"id"`
+	EN string `json:"en"`
+}
+
+type SKPIStudent struct {
+	ID            string `json:"id"`
+	StudentNumber string `json:"student_number"`
+	FullName      string `json:"full_name"`
+	ProgramStudy  string `json:"program_study"`
+	AcademicYear  string `json:"academic_year"`
+}
+
+type SKPIItem struct {
+	AchievementID string     `json:"achievement_id"`
+	Title         string     `json:"title"`
+	Description   SKPIText   `json:"description"`
+	EventDate     *time.Time `json:"event_date,omitempty"`
+	VerifiedAt    *time.Time `json:"verified_at"`
+}
+
+type SKPICategory struct {
+	Key   string     `json:"key"`
+	Name  SKPIText   `json:"name"`
+	Items []SKPIItem `json:"items"`
+}
+
+// SKPIDocument is the achievements section of a student's SKPI (Surat
+// Keterangan Pendamping Ijazah). Until it is finalized it is rebuilt from
+// the current verified achievements on every request.
+type SKPIDocument struct {
+	Institution string         `json:"institution"`
+	Student     SKPIStudent    `json:"student"`
+	Categories  []SKPICategory `json:"categories"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Finalized   bool           `json:"finalized"`
+	FinalizedAt *time.Time     `json:"finalized_at,omitempty"`
+	FinalizedBy *string        `json:"finalized_by,omitempty"`
+}
+
+// SKPISnapshot is the frozen document of a finalized SKPI
+type SKPISnapshot struct {
+	ID          string       `json:"id"`
+	StudentID   string       `json:"student_id"`
+	Document    SKPIDocument `json:"document"`
+	FinalizedBy string       `json:"finalized_by"`
+	FinalizedAt time.Time    `json:"finalized_at"`
+}
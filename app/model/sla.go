@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// StaleSubmission is a submitted achievement waiting for review longer than
+// the SLA allows
+type StaleSubmission struct {
+	AchievementRefID string
+	StudentNumber    string
+	StudentName      string
+	AdvisorUserID    *string
+	SubmittedAt      time.Time
+	ApprovalStep     int
+	ApprovalSteps    []ApprovalStep
+}
+
+// AdvisorReviewLatency summarizes how quickly an advisor reviews submissions
+type AdvisorReviewLatency struct {
+	LecturerID       string  `json:"lecturer_id"`
+	LecturerNumber   string  `json:"lecturer_number"`
+	FullName         string  `json:"full_name"`
+	ReviewedCount    int     `json:"reviewed_count"`
+	AvgReviewHours   float64 `json:"avg_review_hours"`
+	MaxReviewHours   float64 `json:"max_review_hours"`
+	PendingCount     int     `json:"pending_count"`
+	OverdueCount     int     `json:"overdue_count"`
+	OldestPendingHrs float64 `json:"oldest_pending_hours"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// StatisticsBucket is the number of verified achievements and their points
+// for one value of a breakdown
+type StatisticsBucket struct {
+	Key         string `json:"key"`
+	Label       string `json:"label,omitempty"`
+	Count       int    `json:"count"`
+	TotalPoints int    `json:"total_points"`
+}
+
+// AchievementStatistics summarises verified achievements only
+type AchievementStatistics struct {
+	TotalVerified      int                `json:"total_verified"`
+	TotalPoints        int                `json:"total_points"`
+	ByType             []StatisticsBucket `json:"by_type"`
+	ByMonth            []StatisticsBucket `json:"by_month"`
+	BySemester         []StatisticsBucket `json:"by_semester"`
+	ByCompetitionLevel []StatisticsBucket `json:"by_competition_level"`
+	ByProgramStudy     []StatisticsBucket `json:"by_program_study"`
+	ByCohort           []StatisticsBucket `json:"by_cohort"`
+	ByAdvisor          []StatisticsBucket `json:"by_advisor"`
+	ByPeriod           []StatisticsBucket `json:"by_period"`
+}
+
+// StatisticsFilter narrows the verified achievements that are summarised.
+// Expired certifications only count when IncludeExpired is set; PeriodID,
+// when not empty, keeps only achievements assigned to that academic period.
+type StatisticsFilter struct {
+	IncludeExpired bool
+	PeriodID       string
+}
+
+// VerifiedAchievementRow is a verified reference with the attributes of its
+// owning student used for the statistics breakdowns
+type VerifiedAchievementRow struct {
+	AchievementRefID   string
+	MongoAchievementID string
+	StudentID          string
+	ProgramStudy       *string
+	AcademicYear       *string
+	AdvisorID          *string
+	AdvisorName        *string
+	PeriodCode         *string
+	PeriodName         *string
+	VerifiedAt         *time.Time
+}
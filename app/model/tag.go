@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// Tag is an entry of the curated tag vocabulary. Achievements store the
+// canonical Name; Synonyms are other spellings that map to it. Tags form a
+// hierarchy through ParentID.
+type Tag struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	ParentID    *string   `json:"parent_id"`
+	Description *string   `json:"description,omitempty"`
+	Synonyms    []string  `json:"synonyms"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TagRequest creates a tag or replaces all of its fields
+type TagRequest struct {
+	Name        string   `json:"name"`
+	ParentID    *string  `json:"parent_id"`
+	Description *string  `json:"description"`
+	Synonyms    []string `json:"synonyms"`
+}
+
+// TagSuggestion is an autocomplete match. Path lists the tag's ancestors and
+// MatchedSynonym is set when the query matched a synonym.
+type TagSuggestion struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Path           string  `json:"path"`
+	MatchedSynonym *string `json:"matched_synonym,omitempty"`
+}
+
+// DocumentTags are the tags stored on an achievement document
+type DocumentTags struct {
+	ID   string
+	Tags []string
+}
+
+// TagChange is an achievement whose tags the migration rewrites
+type TagChange struct {
+	MongoAchievementID string   `json:"mongo_achievement_id"`
+	Before             []string `json:"before"`
+	After              []string `json:"after"`
+	Applied            bool     `json:"applied"`
+	Error              string   `json:"error,omitempty"`
+}
+
+// TagUsage counts the documents using a tag outside the vocabulary
+type TagUsage struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type TagMigrationReport struct {
+	DryRun           bool         `json:"dry_run"`
+	ScannedDocuments int          `json:"scanned_documents"`
+	Changes          []*TagChange `json:"changes"`
+	UnknownTags      []TagUsage   `json:"unknown_tags"`
+}
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// Team member roles
+const (
+	MemberRoleLeader = "leader"
+	MemberRoleMember = "member"
+)
+
+// Team member confirmation statuses
+const (
+	MemberStatusPending   = "pending"
+	MemberStatusConfirmed = "confirmed"
+	MemberStatusDeclined  = "declined"
+)
+
+// Team point rules. With "full" every member is credited the achievement's
+// points, with "equal" the points are divided among the confirmed members.
+const (
+	TeamPointRuleFull  = "full"
+	TeamPointRuleEqual = "equal"
+)
+
+type AchievementMember struct {
+	AchievementRefID string     `json:"achievement_id"`
+	StudentID        string     `json:"student_id"`
+	StudentNumber    string     `json:"student_number"`
+	FullName         string     `json:"full_name"`
+	Role             string     `json:"role"`
+	Status           string     `json:"status"`
+	Points           float64    `json:"points"`
+	RespondedAt      *time.Time `json:"responded_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// AchievementMemberInput names a co-member by NIM when creating or updating
+// a team achievement
+type AchievementMemberInput struct {
+	StudentNumber string `json:"student_number"`
+	Role          string `json:"role"`
+}
+
+// MemberPoints returns the points credited to each member under the rule
+func MemberPoints(points int, rule string, memberCount int) float64 {
+	if rule == TeamPointRuleEqual && memberCount > 1 {
+		return float64(points) / float64(memberCount)
+	}
+	return float64(points)
+}
@@ -3,19 +3,22 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
+	"log"
+	"regexp"
 	"time"
 
 	"projek_uas/app/model"
 	"projek_uas/database"
 	"projek_uas/helper"
 
-	"github.com/gofiber/fiber/v2"
 	"github.com/lib/pq"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type AchievementRepository struct{}
@@ -59,6 +62,195 @@ func (r *AchievementRepository) FindMongoByID(id string) (*model.Achievement, er
 	return &achievement, nil
 }
 
+// InsertMongoIfAbsent inserts the achievement with its preassigned id. An
+// existing document with that id is left untouched, which makes retries safe.
+func (r *AchievementRepository) InsertMongoIfAbsent(achievement *model.Achievement) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := database.MongoDB.Collection("achievements").InsertOne(ctx, achievement)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// ListMongoKeys returns the id and owner of every achievement document
+func (r *AchievementRepository) ListMongoKeys() ([]model.DocumentKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "studentId": 1})
+	cursor, err := database.MongoDB.Collection("achievements").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []model.DocumentKey{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			StudentID string             `bson:"studentId"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		keys = append(keys, model.DocumentKey{ID: doc.ID.Hex(), StudentID: doc.StudentID})
+	}
+
+	return keys, cursor.Err()
+}
+
+// ListMongoTags returns the tags of every achievement document that has any
+func (r *AchievementRepository) ListMongoTags() ([]model.DocumentTags, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	filter := bson.M{"tags.0": bson.M{"$exists": true}}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "tags": 1})
+	cursor, err := database.MongoDB.Collection("achievements").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	documents := []model.DocumentTags{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID   primitive.ObjectID `bson:"_id"`
+			Tags []string           `bson:"tags"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		documents = append(documents, model.DocumentTags{ID: doc.ID.Hex(), Tags: doc.Tags})
+	}
+
+	return documents, cursor.Err()
+}
+
+// SetMongoTags replaces the tags of an achievement document
+func (r *AchievementRepository) SetMongoTags(id string, tags []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.MongoDB.Collection("achievements").UpdateOne(ctx,
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{"tags": tags}},
+	)
+	return err
+}
+
+// SetMongoStudentID changes the owner recorded in an achievement document
+func (r *AchievementRepository) SetMongoStudentID(id, studentID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.MongoDB.Collection("achievements").UpdateOne(ctx,
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{"studentId": studentID, "updatedAt": time.Now()}},
+	)
+	return err
+}
+
+// ArchiveMongo moves an achievement document to achievements_archive,
+// recording when and why it was archived
+func (r *AchievementRepository) ArchiveMongo(id, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	var doc bson.M
+	err = database.MongoDB.Collection("achievements").FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	doc["archivedAt"] = time.Now()
+	doc["archiveReason"] = reason
+	_, err = database.MongoDB.Collection("achievements_archive").ReplaceOne(ctx,
+		bson.M{"_id": objectID}, doc, options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.MongoDB.Collection("achievements").DeleteOne(ctx, bson.M{"_id": objectID})
+	return err
+}
+
+// achievementSummaryProjection leaves out the fields list views do not need
+var achievementSummaryProjection = bson.M{
+	"description":          0,
+	"attachments":          0,
+	"details.customFields": 0,
+	"details.authors":      0,
+}
+
+// objectIDsFromHex converts hex ids to ObjectIDs, skipping invalid ones
+func objectIDsFromHex(ids []string) []primitive.ObjectID {
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	return objectIDs
+}
+
+// FindMongoByIDs loads many achievements with one query, keyed by hex id.
+// Invalid ids are ignored. With summary set the heavy fields are left out.
+func (r *AchievementRepository) FindMongoByIDs(ids []string, summary bool) (map[string]*model.Achievement, error) {
+	achievements := make(map[string]*model.Achievement, len(ids))
+	if len(ids) == 0 {
+		return achievements, nil
+	}
+
+	objectIDs := objectIDsFromHex(ids)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find()
+	if summary {
+		opts.SetProjection(achievementSummaryProjection)
+	}
+
+	cursor, err := database.MongoDB.Collection("achievements").Find(ctx, bson.M{"_id": bson.M{"$in": objectIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var achievement model.Achievement
+		if err := cursor.Decode(&achievement); err != nil {
+			return nil, err
+		}
+		achievements[achievement.ID.Hex()] = &achievement
+	}
+
+	return achievements, cursor.Err()
+}
+
 func (r *AchievementRepository) UpdateMongo(id string, achievement *model.Achievement) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -85,6 +277,171 @@ func (r *AchievementRepository) UpdateMongo(id string, achievement *model.Achiev
 	return err
 }
 
+// FindMongoDuplicateCandidates returns achievements sharing a certification
+// number, attachment checksum, competition or certification name or event
+// date with the given one, plus the same student's achievements of the same
+// type. Scoring is left to the caller.
+func (r *AchievementRepository) FindMongoDuplicateCandidates(achievement *model.Achievement, limit int64) ([]*model.Achievement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	or := bson.A{
+		bson.M{"studentId": achievement.StudentID, "achievementType": achievement.AchievementType},
+	}
+
+	details := achievement.Details
+	if details.CertificationNumber != "" {
+		or = append(or, bson.M{"details.certificationNumber": details.CertificationNumber})
+	}
+	if details.CompetitionName != "" {
+		or = append(or, bson.M{"details.competitionName": exactInsensitive(details.CompetitionName)})
+	}
+	if details.CertificationName != "" {
+		or = append(or, bson.M{"details.certificationName": exactInsensitive(details.CertificationName)})
+	}
+	if details.EventDate != nil {
+		day := details.EventDate.Truncate(24 * time.Hour)
+		or = append(or, bson.M{"details.eventDate": bson.M{"$gte": day, "$lt": day.Add(24 * time.Hour)}})
+	}
+
+	var checksums []string
+	for _, attachment := range achievement.Attachments {
+		if attachment.Checksum != "" {
+			checksums = append(checksums, attachment.Checksum)
+		}
+	}
+	if len(checksums) > 0 {
+		or = append(or, bson.M{"attachments.checksum": bson.M{"$in": checksums}})
+	}
+
+	filter := bson.M{"_id": bson.M{"$ne": achievement.ID}, "$or": or}
+	cursor, err := database.MongoDB.Collection("achievements").Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var achievements []*model.Achievement
+	if err := cursor.All(ctx, &achievements); err != nil {
+		return nil, err
+	}
+
+	return achievements, nil
+}
+
+// FindMongoCertificationsValidUntil lists the certifications among the given
+// documents whose validity ends on or before the given time, without their
+// heavy fields
+func (r *AchievementRepository) FindMongoCertificationsValidUntil(ids []string, before time.Time) ([]*model.Achievement, error) {
+	if len(ids) == 0 {
+		return []*model.Achievement{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":                bson.M{"$in": objectIDsFromHex(ids)},
+		"achievementType":    "certification",
+		"details.validUntil": bson.M{"$lte": before},
+	}
+	cursor, err := database.MongoDB.Collection("achievements").Find(ctx, filter, options.Find().SetProjection(achievementSummaryProjection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	achievements := []*model.Achievement{}
+	for cursor.Next(ctx) {
+		var achievement model.Achievement
+		if err := cursor.Decode(&achievement); err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, &achievement)
+	}
+	return achievements, cursor.Err()
+}
+
+// SearchMongo runs the text query and structured filters of the search over
+// the given achievements. Results are ordered by text relevance when there
+// is a query, newest first otherwise.
+func (r *AchievementRepository) SearchMongo(filter *model.AchievementSearchFilter, restrictTo []string, limit, offset int) ([]*model.Achievement, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := bson.M{"_id": bson.M{"$in": objectIDsFromHex(restrictTo)}}
+
+	if filter.Query != "" {
+		query["$text"] = bson.M{"$search": filter.Query}
+	}
+	if filter.Type != "" {
+		query["achievementType"] = exactInsensitive(filter.Type)
+	}
+	if filter.Level != "" {
+		query["details.competitionLevel"] = exactInsensitive(filter.Level)
+	}
+	if filter.Medal != "" {
+		query["details.medalType"] = exactInsensitive(filter.Medal)
+	}
+	if len(filter.Tags) > 0 {
+		query["tags"] = bson.M{"$all": filter.Tags}
+	}
+
+	eventDate := bson.M{}
+	if filter.EventFrom != nil {
+		eventDate["$gte"] = *filter.EventFrom
+	}
+	if filter.EventTo != nil {
+		eventDate["$lte"] = *filter.EventTo
+	}
+	if len(eventDate) > 0 {
+		query["details.eventDate"] = eventDate
+	}
+
+	points := bson.M{}
+	if filter.MinPoints != nil {
+		points["$gte"] = *filter.MinPoints
+	}
+	if filter.MaxPoints != nil {
+		points["$lte"] = *filter.MaxPoints
+	}
+	if len(points) > 0 {
+		query["points"] = points
+	}
+
+	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(offset))
+	if filter.Query != "" {
+		opts.SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}})
+		opts.SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "createdAt", Value: -1}})
+	} else {
+		opts.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	}
+
+	collection := database.MongoDB.Collection("achievements")
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var achievements []*model.Achievement
+	if err := cursor.All(ctx, &achievements); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return achievements, total, nil
+}
+
+// exactInsensitive matches the whole value ignoring case
+func exactInsensitive(value string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(value) + "$", Options: "i"}
+}
+
 func (r *AchievementRepository) DeleteMongo(id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -99,570 +456,1021 @@ func (r *AchievementRepository) DeleteMongo(id string) error {
 }
 
 // PostgreSQL operations for achievement references
+const referenceColumns = `
+	id, student_id, mongo_achievement_id, status, submitted_at, verified_at,
+	verified_by, rejection_note, approval_step, team_point_rule, duplicate_of, created_at, updated_at,
+	revoked_at, revoked_by, revocation_reason, deleted_at, deleted_by, expired_at, renewal_of,
+	period_id, approval_chain_id, approval_steps
+`
+
+func scanReference(scanner interface{ Scan(...interface{}) error }) (*model.AchievementReference, error) {
+	ref := &model.AchievementReference{}
+	var approvalSteps []byte
+	err := scanner.Scan(
+		&ref.ID, &ref.StudentID, &ref.MongoAchievementID, &ref.Status,
+		&ref.SubmittedAt, &ref.VerifiedAt, &ref.VerifiedBy, &ref.RejectionNote,
+		&ref.ApprovalStep, &ref.TeamPointRule, &ref.DuplicateOf, &ref.CreatedAt, &ref.UpdatedAt,
+		&ref.RevokedAt, &ref.RevokedBy, &ref.RevocationReason, &ref.DeletedAt, &ref.DeletedBy,
+		&ref.ExpiredAt, &ref.RenewalOf, &ref.PeriodID, &ref.ApprovalChainID, &approvalSteps,
+	)
+	if err != nil {
+		return ref, err
+	}
+	if approvalSteps != nil {
+		if err := json.Unmarshal(approvalSteps, &ref.ApprovalSteps); err != nil {
+			return ref, err
+		}
+	}
+	return ref, nil
+}
+
 func (r *AchievementRepository) CreateReference(ref *model.AchievementReference) error {
+	if ref.TeamPointRule == "" {
+		ref.TeamPointRule = model.TeamPointRuleFull
+	}
+
 	query := `
-		INSERT INTO achievement_references (student_id, mongo_achievement_id, status)
-		VALUES ($1, $2, $3)
+		INSERT INTO achievement_references (student_id, mongo_achievement_id, status, team_point_rule, period_id)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at
 	`
-	return database.PostgresDB.QueryRow(query, ref.StudentID, ref.MongoAchievementID, ref.Status).
+	return database.PostgresDB.QueryRow(query, ref.StudentID, ref.MongoAchievementID, ref.Status, ref.TeamPointRule, ref.PeriodID).
 		Scan(&ref.ID, &ref.CreatedAt, &ref.UpdatedAt)
 }
 
-func (r *AchievementRepository) FindReferenceByID(id string) (*model.AchievementReference, error) {
-	ref := &model.AchievementReference{}
+// CreateReferenceWithOutbox stores the reference, its team and the event that
+// creates its MongoDB document in one transaction, so none exists without
+// the others
+func (r *AchievementRepository) CreateReferenceWithOutbox(ref *model.AchievementReference, members []*model.AchievementMember, event *model.OutboxEvent) error {
+	if ref.TeamPointRule == "" {
+		ref.TeamPointRule = model.TeamPointRuleFull
+	}
+
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
-		SELECT id, student_id, mongo_achievement_id, status, submitted_at, verified_at,
-		       verified_by, rejection_note, created_at, updated_at
-		FROM achievement_references WHERE id = $1
+		INSERT INTO achievement_references (student_id, mongo_achievement_id, status, team_point_rule, period_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
 	`
-	err := database.PostgresDB.QueryRow(query, id).Scan(
-		&ref.ID, &ref.StudentID, &ref.MongoAchievementID, &ref.Status,
-		&ref.SubmittedAt, &ref.VerifiedAt, &ref.VerifiedBy, &ref.RejectionNote,
-		&ref.CreatedAt, &ref.UpdatedAt,
-	)
+	err = tx.QueryRow(query, ref.StudentID, ref.MongoAchievementID, ref.Status, ref.TeamPointRule, ref.PeriodID).
+		Scan(&ref.ID, &ref.CreatedAt, &ref.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if members != nil {
+		if err := replaceMembers(tx, ref.ID, members); err != nil {
+			return err
+		}
+	}
+
+	event.AchievementRefID = ref.ID
+	if err := insertOutboxEvent(tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PurgeReferenceWithOutbox permanently removes a reference soft deleted
+// before deletedBefore and records the event that deletes its MongoDB
+// document in the same transaction. It reports false when the reference was
+// restored or purged in the meantime.
+func (r *AchievementRepository) PurgeReferenceWithOutbox(ref *model.AchievementReference, event *model.OutboxEvent, deletedBefore time.Time) (bool, error) {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM achievement_references WHERE id = $1 AND deleted_at < $2", ref.ID, deletedBefore)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	event.AchievementRefID = ref.ID
+	if err := insertOutboxEvent(tx, event); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// SoftDeleteReference hides the reference until it is restored or purged
+func (r *AchievementRepository) SoftDeleteReference(id, deletedBy string) error {
+	now := time.Now()
+	result, err := database.PostgresDB.Exec(`
+		UPDATE achievement_references
+		SET deleted_at = $1, deleted_by = $2, updated_at = $1
+		WHERE id = $3 AND deleted_at IS NULL
+	`, now, deletedBy, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("achievement not found or already deleted")
+	}
+
+	return nil
+}
+
+// RestoreReference brings back a soft deleted reference
+func (r *AchievementRepository) RestoreReference(id string) error {
+	result, err := database.PostgresDB.Exec(`
+		UPDATE achievement_references
+		SET deleted_at = NULL, deleted_by = NULL, updated_at = $1
+		WHERE id = $2 AND deleted_at IS NOT NULL
+	`, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("achievement not found or not deleted")
+	}
+
+	return nil
+}
+
+// FindReferenceByID returns the reference unless it is soft deleted
+func (r *AchievementRepository) FindReferenceByID(id string) (*model.AchievementReference, error) {
+	query := "SELECT " + referenceColumns + " FROM achievement_references WHERE id = $1 AND deleted_at IS NULL"
+	ref, err := scanReference(database.PostgresDB.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return ref, err
+}
+
+// FindDeletedReferenceByID returns the reference only if it is soft deleted
+func (r *AchievementRepository) FindDeletedReferenceByID(id string) (*model.AchievementReference, error) {
+	query := "SELECT " + referenceColumns + " FROM achievement_references WHERE id = $1 AND deleted_at IS NOT NULL"
+	ref, err := scanReference(database.PostgresDB.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	return ref, err
+	return ref, err
+}
+
+// ListDeletedReferences lists soft deleted references, most recently deleted
+// first. An empty studentID lists those of every student.
+func (r *AchievementRepository) ListDeletedReferences(studentID string) ([]*model.AchievementReference, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT `+referenceColumns+`
+		FROM achievement_references
+		WHERE deleted_at IS NOT NULL AND ($1 = '' OR student_id::text = $1)
+		ORDER BY deleted_at DESC
+	`, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := []*model.AchievementReference{}
+	for rows.Next() {
+		ref, err := scanReference(rows)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// FindPurgeCandidates lists up to limit references soft deleted before the
+// given time, oldest first
+func (r *AchievementRepository) FindPurgeCandidates(deletedBefore time.Time, limit int) ([]*model.AchievementReference, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT `+referenceColumns+`
+		FROM achievement_references
+		WHERE deleted_at < $1
+		ORDER BY deleted_at ASC
+		LIMIT $2
+	`, deletedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := []*model.AchievementReference{}
+	for rows.Next() {
+		ref, err := scanReference(rows)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// referenceScope builds the WHERE clause selecting references owned by the
+// given students, including team achievements they take part in. An empty
+// studentIDs selects everything. Soft deleted references are left out.
+func referenceScope(studentIDs []string, status string) (string, []interface{}) {
+	where := " WHERE deleted_at IS NULL"
+	var args []interface{}
+	argIndex := 1
+
+	if len(studentIDs) > 0 {
+		where += `
+			AND (student_id = ANY($1) OR id IN (
+				SELECT achievement_ref_id FROM achievement_members
+				WHERE student_id = ANY($1) AND status <> 'declined'
+			))
+		`
+		args = append(args, pq.Array(studentIDs))
+		argIndex++
+	}
+
+	if status != "" {
+		where += fmt.Sprintf(" AND status = $%d", argIndex)
+		args = append(args, status)
+	}
+
+	return where, args
+}
+
+func (r *AchievementRepository) ListReferences(studentIDs []string, status string, limit, offset int) ([]*model.AchievementReference, error) {
+	where, args := referenceScope(studentIDs, status)
+	query := "SELECT " + referenceColumns + " FROM achievement_references" + where +
+		fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+
+	rows, err := database.PostgresDB.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := []*model.AchievementReference{}
+	for rows.Next() {
+		ref, err := scanReference(rows)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+// FindVerifiedForStudent lists the verified achievements credited to the
+// student, as owner or team member, oldest verification first. Duplicates
+// are left out.
+func (r *AchievementRepository) FindVerifiedForStudent(studentID string) ([]*model.AchievementReference, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT `+referenceColumns+`
+		FROM achievement_references
+		WHERE status = 'verified' AND duplicate_of IS NULL AND deleted_at IS NULL AND (student_id = $1 OR id IN (
+			SELECT achievement_ref_id FROM achievement_members
+			WHERE student_id = $1 AND status <> 'declined'
+		))
+		ORDER BY verified_at ASC, id ASC
+	`, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := []*model.AchievementReference{}
+	for rows.Next() {
+		ref, err := scanReference(rows)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// ListReferencesByCursor lists up to limit references after the cursor,
+// newest first. more reports whether further rows exist in the direction of
+// the cursor.
+func (r *AchievementRepository) ListReferencesByCursor(studentIDs []string, status string, cursor *model.Cursor, limit int) ([]*model.AchievementReference, bool, error) {
+	where, args := referenceScope(studentIDs, status)
+	condition, order, cursorArgs := keysetCondition(cursor, "created_at", "id", len(args)+1)
+	args = append(args, cursorArgs...)
+
+	query := "SELECT " + referenceColumns + " FROM achievement_references" + where + condition + order +
+		fmt.Sprintf(" LIMIT $%d", len(args)+1)
+
+	rows, err := database.PostgresDB.Query(query, append(args, limit+1)...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	refs := []*model.AchievementReference{}
+	for rows.Next() {
+		ref, err := scanReference(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	more := len(refs) > limit
+	if more {
+		refs = refs[:limit]
+	}
+	if cursor != nil && cursor.Backward {
+		for i, j := 0, len(refs)-1; i < j; i, j = i+1, j-1 {
+			refs[i], refs[j] = refs[j], refs[i]
+		}
+	}
+
+	return refs, more, nil
+}
+
+func (r *AchievementRepository) CountReferences(studentIDs []string, status string) (int64, error) {
+	where, args := referenceScope(studentIDs, status)
+
+	var total int64
+	err := database.PostgresDB.QueryRow("SELECT COUNT(*) FROM achievement_references"+where, args...).Scan(&total)
+	return total, err
+}
+
+// GetReferences lists a page of references with their MongoDB documents
+// loaded in one batch. The total is counted concurrently. With summary set
+// the documents leave out heavy fields, see FindMongoByIDs.
+func (r *AchievementRepository) GetReferences(studentIDs []string, status string, limit, offset int, summary bool) ([]*model.AchievementReference, int64, error) {
+	type countResult struct {
+		total int64
+		err   error
+	}
+	countCh := make(chan countResult, 1)
+	go func() {
+		total, err := r.CountReferences(studentIDs, status)
+		countCh <- countResult{total, err}
+	}()
+
+	refs, err := r.ListReferences(studentIDs, status, limit, offset)
+	if err == nil {
+		err = r.AttachAchievements(refs, summary)
+	}
+
+	count := <-countCh
+	if err != nil {
+		return nil, 0, err
+	}
+	if count.err != nil {
+		return nil, 0, count.err
+	}
+
+	return refs, count.total, nil
+}
+
+// AttachAchievements loads the MongoDB documents of the references with a
+// single query. References whose document is missing are logged and left
+// without one.
+func (r *AchievementRepository) AttachAchievements(refs []*model.AchievementReference, summary bool) error {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, ref.MongoAchievementID)
+	}
+
+	achievements, err := r.FindMongoByIDs(ids, summary)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		achievement, ok := achievements[ref.MongoAchievementID]
+		if !ok {
+			log.Printf("achievement %s: mongo document %s not found", ref.ID, ref.MongoAchievementID)
+			continue
+		}
+		ref.Achievement = achievement
+	}
+
+	return nil
 }
 
-func (r *AchievementRepository) GetReferences(studentIDs []string, status string, limit, offset int) ([]*model.AchievementReference, int64, error) {
-	var query string
-	var args []interface{}
-	argIndex := 1
+// execer is implemented by both *sql.DB and *sql.Tx, so the same insert
+// can run on its own or inside a transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
 
-	if len(studentIDs) > 0 {
-		query = `
-			SELECT id, student_id, mongo_achievement_id, status, submitted_at, verified_at,
-			       verified_by, rejection_note, created_at, updated_at
-			FROM achievement_references
-			WHERE student_id = ANY($1)
-		`
-		args = append(args, pq.Array(studentIDs))
-		argIndex++
-	} else {
-		query = `
-			SELECT id, student_id, mongo_achievement_id, status, submitted_at, verified_at,
-			       verified_by, rejection_note, created_at, updated_at
-			FROM achievement_references
-			WHERE 1=1
-		`
+// ApplyTransition moves the reference to its new status, or to the next
+// approval step, and records the history entry, approval and comment of the
+// transition in one transaction. It fails without writing anything when
+// another request moved the reference first.
+func (r *AchievementRepository) ApplyTransition(t *model.StatusTransition) error {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	if status != "" {
-		query += fmt.Sprintf(" AND status = $%d", argIndex)
-		args = append(args, status)
-		argIndex++
+	if t.ToStatus == t.FromStatus {
+		err = advanceApprovalStep(tx, t.AchievementRefID, t.FromStatus, t.FromStep)
+	} else {
+		err = updateReferenceStatus(tx, t)
 	}
-
-	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
-	args = append(args, limit, offset)
-
-	rows, err := database.PostgresDB.Query(query, args...)
 	if err != nil {
-		return nil, 0, err
+		return err
 	}
-	defer rows.Close()
 
-	var refs []*model.AchievementReference
-	for rows.Next() {
-		ref := &model.AchievementReference{}
-		err := rows.Scan(
-			&ref.ID, &ref.StudentID, &ref.MongoAchievementID, &ref.Status,
-			&ref.SubmittedAt, &ref.VerifiedAt, &ref.VerifiedBy, &ref.RejectionNote,
-			&ref.CreatedAt, &ref.UpdatedAt,
-		)
-		if err != nil {
-			return nil, 0, err
+	if t.Change != nil {
+		if err := insertStatusChange(tx, t.Change); err != nil {
+			return err
 		}
-		refs = append(refs, ref)
 	}
-
-	var countQuery string
-	var countArgs []interface{}
-	if len(studentIDs) > 0 {
-		countQuery = "SELECT COUNT(*) FROM achievement_references WHERE student_id = ANY($1)"
-		countArgs = append(countArgs, pq.Array(studentIDs))
-		if status != "" {
-			countQuery += " AND status = $2"
-			countArgs = append(countArgs, status)
+	if t.Approval != nil {
+		if err := insertApproval(tx, t.Approval); err != nil {
+			return err
 		}
-	} else {
-		countQuery = "SELECT COUNT(*) FROM achievement_references WHERE 1=1"
-		if status != "" {
-			countQuery += " AND status = $1"
-			countArgs = append(countArgs, status)
+	}
+	if t.Comment != nil {
+		if err := insertComment(tx, t.Comment); err != nil {
+			return err
 		}
 	}
 
-	var total int64
-	err = database.PostgresDB.QueryRow(countQuery, countArgs...).Scan(&total)
-
-	return refs, total, err
+	return tx.Commit()
 }
 
-func (r *AchievementRepository) UpdateReferenceStatus(id, status string, verifiedBy *string, note *string) error {
+func updateReferenceStatus(db execer, t *model.StatusTransition) error {
 	now := time.Now()
 	query := `
 		UPDATE achievement_references
 		SET status = $1, updated_at = $2
 	`
-	args := []interface{}{status, now}
+	args := []interface{}{t.ToStatus, now}
 	argIndex := 3
 
-	if status == "submitted" {
-		query += fmt.Sprintf(", submitted_at = $%d", argIndex)
-		args = append(args, now)
-		argIndex++
-	} else if status == "verified" {
+	if t.ToStatus == "submitted" {
+		approvalSteps, err := json.Marshal(t.ApprovalSteps)
+		if err != nil {
+			return err
+		}
+		query += fmt.Sprintf(
+			", submitted_at = $%d, approval_step = 0, approval_chain_id = $%d, approval_steps = $%d, reminder_sent_at = NULL, escalated_at = NULL",
+			argIndex, argIndex+1, argIndex+2,
+		)
+		args = append(args, now, t.ApprovalChainID, approvalSteps)
+		argIndex += 3
+	} else if t.ToStatus == "verified" {
 		query += fmt.Sprintf(", verified_at = $%d, verified_by = $%d", argIndex, argIndex+1)
-		args = append(args, now, verifiedBy)
+		args = append(args, now, t.ReviewerID)
 		argIndex += 2
-	} else if status == "rejected" {
+	} else if t.ToStatus == "rejected" {
 		query += fmt.Sprintf(", verified_by = $%d, rejection_note = $%d", argIndex, argIndex+1)
-		args = append(args, verifiedBy, note)
+		args = append(args, t.ReviewerID, t.Note)
 		argIndex += 2
+	} else if t.ToStatus == "needs_revision" {
+		query += fmt.Sprintf(", verified_by = $%d", argIndex)
+		args = append(args, t.ReviewerID)
+		argIndex++
+	} else if t.ToStatus == "revoked" {
+		// verified_at and verified_by are kept so the original decision stays visible
+		query += fmt.Sprintf(", revoked_at = $%d, revoked_by = $%d, revocation_reason = $%d", argIndex, argIndex+1, argIndex+2)
+		args = append(args, now, t.ReviewerID, t.Note)
+		argIndex += 3
 	}
 
-	query += fmt.Sprintf(" WHERE id = $%d", argIndex)
-	args = append(args, id)
-
-	_, err := database.PostgresDB.Exec(query, args...)
-	return err
-}
-
-func (r *AchievementRepository) DeleteReference(id string) error {
-	_, err := database.PostgresDB.Exec("DELETE FROM achievement_references WHERE id = $1", id)
-	return err
-}
-
-func (r *AchievementRepository) GetStatistics(studentIDs []string) (map[string]interface{}, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	matchStage := bson.M{}
-	if len(studentIDs) > 0 {
-		matchStage["studentId"] = bson.M{"$in": studentIDs}
-	}
+	query += fmt.Sprintf(" WHERE id = $%d AND status = $%d AND deleted_at IS NULL", argIndex, argIndex+1)
+	args = append(args, t.AchievementRefID, t.FromStatus)
 
-	pipeline := []bson.M{}
-	if len(matchStage) > 0 {
-		pipeline = append(pipeline, bson.M{"$match": matchStage})
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return err
 	}
 
-	pipeline = append(pipeline, bson.M{
-		"$group": bson.M{
-			"_id":         "$achievementType",
-			"count":       bson.M{"$sum": 1},
-			"totalPoints": bson.M{"$sum": "$points"},
-		},
-	})
-
-	cursor, err := database.MongoDB.Collection("achievements").Aggregate(ctx, pipeline)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer cursor.Close(ctx)
 
-	var results []bson.M
-	if err := cursor.All(ctx, &results); err != nil {
-		return nil, err
+	if rowsAffected == 0 {
+		return fmt.Errorf("achievement is no longer %s", t.FromStatus)
 	}
 
-	stats := map[string]interface{}{
-		"by_type": results,
-	}
+	return nil
+}
 
-	return stats, nil
+// insertStatusChange appends an entry to the achievement's status history
+func insertStatusChange(db execer, change *model.AchievementStatusChange) error {
+	return db.QueryRow(`
+		INSERT INTO achievement_status_history (achievement_ref_id, from_status, to_status, action, actor_id, note)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, change.AchievementRefID, change.FromStatus, change.ToStatus, change.Action, change.ActorID, change.Note,
+	).Scan(&change.ID, &change.CreatedAt)
 }
 
-// HTTP handler methods integrated into repository
-func (r *AchievementRepository) HandleCreate(userID string, req *model.CreateAchievementRequest, studentRepo *StudentRepository) (*model.AchievementReference, error) {
-	student, err := studentRepo.FindByUserID(userID)
+// FindStatusHistory lists the status changes of an achievement, oldest first
+func (r *AchievementRepository) FindStatusHistory(achievementRefID string) ([]*model.AchievementStatusChange, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT h.id, h.achievement_ref_id, h.from_status, h.to_status, h.action, h.actor_id, u.full_name, h.note, h.created_at
+		FROM achievement_status_history h
+		LEFT JOIN users u ON h.actor_id = u.id
+		WHERE h.achievement_ref_id = $1
+		ORDER BY h.created_at ASC
+	`, achievementRefID)
 	if err != nil {
 		return nil, err
 	}
-	if student == nil {
-		return nil, errors.New("student profile not found")
-	}
+	defer rows.Close()
 
-	achievement := &model.Achievement{
-		StudentID:       student.ID,
-		AchievementType: req.AchievementType,
-		Title:           req.Title,
-		Description:     req.Description,
-		Details:         req.Details,
-		Tags:            req.Tags,
-		Points:          req.Points,
-		Attachments:     []model.Attachment{},
+	history := []*model.AchievementStatusChange{}
+	for rows.Next() {
+		change := &model.AchievementStatusChange{}
+		err := rows.Scan(
+			&change.ID, &change.AchievementRefID, &change.FromStatus, &change.ToStatus, &change.Action,
+			&change.ActorID, &change.ActorName, &change.Note, &change.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, change)
 	}
+	return history, rows.Err()
+}
 
-	if err := r.CreateMongo(achievement); err != nil {
-		return nil, err
+// advanceApprovalStep marks one more approval step as completed while the
+// achievement keeps its status. The reminder is cleared so the approver of
+// the next step is reminded in turn. It fails when another approver already
+// completed the same step.
+func advanceApprovalStep(db execer, id, status string, currentStep int) error {
+	query := `
+		UPDATE achievement_references
+		SET approval_step = approval_step + 1, reminder_sent_at = NULL, updated_at = $1
+		WHERE id = $2 AND approval_step = $3 AND status = $4 AND deleted_at IS NULL
+	`
+	result, err := db.Exec(query, time.Now(), id, currentStep, status)
+	if err != nil {
+		return err
 	}
 
-	ref := &model.AchievementReference{
-		StudentID:          student.ID,
-		MongoAchievementID: achievement.ID.Hex(),
-		Status:             "draft",
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
 	}
 
-	if err := r.CreateReference(ref); err != nil {
-		return nil, err
+	if rowsAffected == 0 {
+		return errors.New("approval step was already decided")
 	}
 
-	ref.Achievement = achievement
-	return ref, nil
+	return nil
 }
 
-func (r *AchievementRepository) HandleGetAll(userID, roleName, status string, page, limit int, studentRepo *StudentRepository, lecturerRepo *LecturerRepository) ([]*model.AchievementReference, *model.Pagination, error) {
-	offset := (page - 1) * limit
-	var studentIDs []string
-	var err error
-
-	if roleName == "Mahasiswa" {
-		student, err := studentRepo.FindByUserID(userID)
-		if err != nil {
-			return nil, nil, err
-		}
-		if student != nil {
-			studentIDs = []string{student.ID}
-		}
-	} else if roleName == "Dosen Wali" {
-		lecturer, err := lecturerRepo.FindByUserID(userID)
-		if err != nil {
-			return nil, nil, err
-		}
-		if lecturer != nil {
-			studentIDs, err = studentRepo.GetStudentsByAdvisorID(lecturer.ID)
-			if err != nil {
-				return nil, nil, err
-			}
-		}
+// FindReferencesByMongoIDs maps MongoDB achievement ids to their references
+func (r *AchievementRepository) FindReferencesByMongoIDs(mongoIDs []string) (map[string]*model.AchievementReference, error) {
+	refs := make(map[string]*model.AchievementReference)
+	if len(mongoIDs) == 0 {
+		return refs, nil
 	}
 
-	refs, total, err := r.GetReferences(studentIDs, status, limit, offset)
+	query := "SELECT " + referenceColumns + " FROM achievement_references WHERE mongo_achievement_id = ANY($1) AND deleted_at IS NULL"
+	rows, err := database.PostgresDB.Query(query, pq.Array(mongoIDs))
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	for _, ref := range refs {
-		achievement, err := r.FindMongoByID(ref.MongoAchievementID)
-		if err == nil {
-			ref.Achievement = achievement
+	for rows.Next() {
+		ref, err := scanReference(rows)
+		if err != nil {
+			return nil, err
 		}
+		refs[ref.MongoAchievementID] = ref
 	}
 
-	totalPages := int(total) / limit
-	if int(total)%limit != 0 {
-		totalPages++
-	}
-
-	pagination := &model.Pagination{
-		Page:       page,
-		Limit:      limit,
-		TotalItems: total,
-		TotalPages: totalPages,
-	}
-
-	return refs, pagination, nil
+	return refs, rows.Err()
 }
 
-func (r *AchievementRepository) HandleGetByID(id, userID, roleName string, studentRepo *StudentRepository, lecturerRepo *LecturerRepository) (*model.AchievementReference, error) {
-	ref, err := r.FindReferenceByID(id)
-	if err != nil {
-		return nil, err
+// FindMongoIDsForSearch returns the MongoDB ids of the achievements the
+// search may return, narrowed by owner (including team membership), status
+// and the owner's program study
+func (r *AchievementRepository) FindMongoIDsForSearch(studentIDs []string, status, programStudy string) ([]string, error) {
+	query := `
+		SELECT ar.mongo_achievement_id
+		FROM achievement_references ar
+		JOIN students s ON ar.student_id = s.id
+		WHERE ar.deleted_at IS NULL
+	`
+	var args []interface{}
+	argIndex := 1
+
+	if len(studentIDs) > 0 {
+		query += fmt.Sprintf(`
+			AND (ar.student_id = ANY($%d) OR ar.id IN (
+				SELECT achievement_ref_id FROM achievement_members
+				WHERE student_id = ANY($%d) AND status <> 'declined'
+			))
+		`, argIndex, argIndex)
+		args = append(args, pq.Array(studentIDs))
+		argIndex++
 	}
-	if ref == nil {
-		return nil, errors.New("achievement not found")
+	if status != "" {
+		query += fmt.Sprintf(" AND ar.status = $%d", argIndex)
+		args = append(args, status)
+		argIndex++
 	}
-
-	if roleName == "Mahasiswa" {
-		student, err := studentRepo.FindByUserID(userID)
-		if err != nil {
-			return nil, err
-		}
-		if student == nil || student.ID != ref.StudentID {
-			return nil, errors.New("unauthorized")
-		}
-	} else if roleName == "Dosen Wali" {
-		lecturer, err := lecturerRepo.FindByUserID(userID)
-		if err != nil {
-			return nil, err
-		}
-		if lecturer != nil {
-			studentIDs, err := studentRepo.GetStudentsByAdvisorID(lecturer.ID)
-			if err != nil {
-				return nil, err
-			}
-			authorized := false
-			for _, sid := range studentIDs {
-				if sid == ref.StudentID {
-					authorized = true
-					break
-				}
-			}
-			if !authorized {
-				return nil, errors.New("unauthorized")
-			}
-		}
+	if programStudy != "" {
+		query += fmt.Sprintf(" AND LOWER(s.program_study) = LOWER($%d)", argIndex)
+		args = append(args, programStudy)
+		argIndex++
 	}
 
-	achievement, err := r.FindMongoByID(ref.MongoAchievementID)
+	rows, err := database.PostgresDB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
-	ref.Achievement = achievement
+	defer rows.Close()
 
-	return ref, nil
+	mongoIDs := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		mongoIDs = append(mongoIDs, id)
+	}
+	return mongoIDs, rows.Err()
 }
 
-func (r *AchievementRepository) HandleUpdate(id, userID string, req *model.UpdateAchievementRequest, studentRepo *StudentRepository) error {
-	ref, err := r.FindReferenceByID(id)
+// ListReferenceKeys returns the id, owner and document id of every reference
+func (r *AchievementRepository) ListReferenceKeys() ([]model.ReferenceKey, error) {
+	rows, err := database.PostgresDB.Query("SELECT id, student_id, mongo_achievement_id FROM achievement_references")
 	if err != nil {
-		return err
-	}
-	if ref == nil {
-		return errors.New("achievement not found")
+		return nil, err
 	}
+	defer rows.Close()
 
-	student, err := studentRepo.FindByUserID(userID)
-	if err != nil {
-		return err
-	}
-	if student == nil || student.ID != ref.StudentID {
-		return errors.New("unauthorized")
+	keys := []model.ReferenceKey{}
+	for rows.Next() {
+		var key model.ReferenceKey
+		if err := rows.Scan(&key.ID, &key.StudentID, &key.MongoAchievementID); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
 	}
+	return keys, rows.Err()
+}
 
-	if ref.Status != "draft" && ref.Status != "rejected" {
-		return errors.New("cannot update achievement in current status")
-	}
+func (r *AchievementRepository) SetDuplicateOf(id string, duplicateOf *string) error {
+	_, err := database.PostgresDB.Exec(
+		"UPDATE achievement_references SET duplicate_of = $1, updated_at = $2 WHERE id = $3",
+		duplicateOf, time.Now(), id,
+	)
+	return err
+}
 
-	achievement := &model.Achievement{
-		Title:       req.Title,
-		Description: req.Description,
-		Details:     req.Details,
-		Tags:        req.Tags,
-		Points:      req.Points,
-	}
+func (r *AchievementRepository) SetRenewalOf(id string, renewalOf *string) error {
+	_, err := database.PostgresDB.Exec(
+		"UPDATE achievement_references SET renewal_of = $1, updated_at = $2 WHERE id = $3",
+		renewalOf, time.Now(), id,
+	)
+	return err
+}
 
-	return r.UpdateMongo(ref.MongoAchievementID, achievement)
+// SetReferencePeriod assigns the reference to an academic period, or to none
+// when periodID is nil
+func (r *AchievementRepository) SetReferencePeriod(id string, periodID *string) error {
+	_, err := database.PostgresDB.Exec(
+		"UPDATE achievement_references SET period_id = $1 WHERE id = $2",
+		periodID, id,
+	)
+	return err
 }
 
-func (r *AchievementRepository) HandleDelete(id, userID string, studentRepo *StudentRepository) error {
-	ref, err := r.FindReferenceByID(id)
-	if err != nil {
-		return err
-	}
-	if ref == nil {
-		return errors.New("achievement not found")
+// AssignPeriod assigns the given references to an academic period, or to
+// none when periodID is nil
+func (r *AchievementRepository) AssignPeriod(periodID *string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
 	}
+	_, err := database.PostgresDB.Exec(
+		"UPDATE achievement_references SET period_id = $1 WHERE id = ANY($2)",
+		periodID, pq.Array(ids),
+	)
+	return err
+}
 
-	student, err := studentRepo.FindByUserID(userID)
+// ListReferencePeriodKeys returns what is needed to reassign every
+// reference, soft deleted ones included, to its academic period
+func (r *AchievementRepository) ListReferencePeriodKeys() ([]model.ReferencePeriodKey, error) {
+	rows, err := database.PostgresDB.Query("SELECT id, mongo_achievement_id, created_at, period_id FROM achievement_references")
 	if err != nil {
-		return err
-	}
-	if student == nil || student.ID != ref.StudentID {
-		return errors.New("unauthorized")
+		return nil, err
 	}
+	defer rows.Close()
 
-	if ref.Status != "draft" {
-		return errors.New("can only delete draft achievements")
+	keys := []model.ReferencePeriodKey{}
+	for rows.Next() {
+		var key model.ReferencePeriodKey
+		if err := rows.Scan(&key.ID, &key.MongoAchievementID, &key.CreatedAt, &key.PeriodID); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
 	}
+	return keys, rows.Err()
+}
 
-	if err := r.DeleteMongo(ref.MongoAchievementID); err != nil {
-		return err
+// MarkExpired records when a verified certification expired. It reports
+// false when the certification was already marked.
+func (r *AchievementRepository) MarkExpired(id string, expiredAt time.Time) (bool, error) {
+	result, err := database.PostgresDB.Exec(
+		"UPDATE achievement_references SET expired_at = $1, updated_at = $2 WHERE id = $3 AND expired_at IS NULL",
+		expiredAt, time.Now(), id,
+	)
+	if err != nil {
+		return false, err
 	}
-
-	return r.DeleteReference(id)
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
 }
 
-func (r *AchievementRepository) HandleSubmit(id, userID string, studentRepo *StudentRepository) error {
-	ref, err := r.FindReferenceByID(id)
+// ClaimExpiryNotice marks the advance expiry notice of a certification as
+// sent. It reports false when the notice was already sent.
+func (r *AchievementRepository) ClaimExpiryNotice(id string) (bool, error) {
+	result, err := database.PostgresDB.Exec(
+		"UPDATE achievement_references SET expiry_notified_at = $1 WHERE id = $2 AND expiry_notified_at IS NULL",
+		time.Now(), id,
+	)
 	if err != nil {
-		return err
-	}
-	if ref == nil {
-		return errors.New("achievement not found")
+		return false, err
 	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
 
-	student, err := studentRepo.FindByUserID(userID)
+// FindUnexpiredVerifiedMongoIDs lists the documents of verified achievements
+// that have not been marked expired yet
+func (r *AchievementRepository) FindUnexpiredVerifiedMongoIDs() ([]string, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT mongo_achievement_id FROM achievement_references
+		WHERE status = 'verified' AND expired_at IS NULL AND deleted_at IS NULL
+	`)
 	if err != nil {
-		return err
-	}
-	if student == nil || student.ID != ref.StudentID {
-		return errors.New("unauthorized")
+		return nil, err
 	}
+	defer rows.Close()
 
-	if ref.Status != "draft" && ref.Status != "rejected" {
-		return errors.New("achievement already submitted")
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
 	}
-
-	return r.UpdateReferenceStatus(id, "submitted", nil, nil)
+	return ids, rows.Err()
 }
 
-func (r *AchievementRepository) HandleVerify(id, userID string, req *model.VerifyAchievementRequest) error {
-	ref, err := r.FindReferenceByID(id)
-	if err != nil {
-		return err
-	}
-	if ref == nil {
-		return errors.New("achievement not found")
+// FindRenewedReferenceIDs returns which of the given references already have
+// a verified renewal
+func (r *AchievementRepository) FindRenewedReferenceIDs(ids []string) (map[string]bool, error) {
+	renewed := make(map[string]bool)
+	if len(ids) == 0 {
+		return renewed, nil
 	}
 
-	if ref.Status != "submitted" {
-		return errors.New("achievement is not in submitted status")
+	rows, err := database.PostgresDB.Query(`
+		SELECT DISTINCT renewal_of FROM achievement_references
+		WHERE renewal_of = ANY($1) AND status = 'verified' AND deleted_at IS NULL
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	if req.Action == "verify" {
-		return r.UpdateReferenceStatus(id, "verified", &userID, nil)
-	} else if req.Action == "reject" {
-		return r.UpdateReferenceStatus(id, "rejected", &userID, &req.Note)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		renewed[id] = true
 	}
-
-	return errors.New("invalid action")
+	return renewed, rows.Err()
 }
 
-func (r *AchievementRepository) HandleStatistics(userID, roleName string, studentRepo *StudentRepository, lecturerRepo *LecturerRepository) (map[string]interface{}, error) {
-	var studentIDs []string
+// UpdateTeam replaces the members and the point rule of an achievement
+// together. A nil members list or an empty rule leaves that part as it is.
+func (r *AchievementRepository) UpdateTeam(id string, members []*model.AchievementMember, rule string) error {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	if roleName == "Mahasiswa" {
-		student, err := studentRepo.FindByUserID(userID)
-		if err != nil {
-			return nil, err
+	if members != nil {
+		if err := replaceMembers(tx, id, members); err != nil {
+			return err
 		}
-		if student != nil {
-			studentIDs = []string{student.ID}
-		}
-	} else if roleName == "Dosen Wali" {
-		lecturer, err := lecturerRepo.FindByUserID(userID)
+	}
+	if rule != "" {
+		_, err := tx.Exec(
+			"UPDATE achievement_references SET team_point_rule = $1, updated_at = $2 WHERE id = $3",
+			rule, time.Now(), id,
+		)
 		if err != nil {
-			return nil, err
-		}
-		if lecturer != nil {
-			var err error
-			studentIDs, err = studentRepo.GetStudentsByAdvisorID(lecturer.ID)
-			if err != nil {
-				return nil, err
-			}
+			return err
 		}
 	}
 
-	return r.GetStatistics(studentIDs)
+	return tx.Commit()
 }
 
-func (r *AchievementRepository) HandleCreateHTTP(c *fiber.Ctx, studentRepo *StudentRepository) error {
-	userID := c.Locals("userID").(string)
-
-	var req model.CreateAchievementRequest
-	if err := c.BodyParser(&req); err != nil {
-		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
-	}
-
-	achievement, err := r.HandleCreate(userID, &req, studentRepo)
+// DeleteDraftReference removes a reference that is still a draft. It reports
+// false when the reference is gone or has moved on in the workflow.
+func (r *AchievementRepository) DeleteDraftReference(id string) (bool, error) {
+	result, err := database.PostgresDB.Exec(
+		"DELETE FROM achievement_references WHERE id = $1 AND status = 'draft'", id)
 	if err != nil {
-		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return false, err
 	}
 
-	return helper.SuccessResponse(c, "Achievement created successfully", achievement)
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
 }
 
-func (r *AchievementRepository) HandleGetAllHTTP(c *fiber.Ctx, studentRepo *StudentRepository, lecturerRepo *LecturerRepository) error {
-	userID := c.Locals("userID").(string)
-	roleName := c.Locals("roleName").(string)
-	status := c.Query("status", "")
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-
-	achievements, pagination, err := r.HandleGetAll(userID, roleName, status, page, limit, studentRepo, lecturerRepo)
+// GetStatistics aggregates the verified achievements of the given students,
+// including team achievements they take part in. The verified set comes from
+// PostgreSQL, the documents are then loaded from MongoDB in one batch.
+func (r *AchievementRepository) GetStatistics(studentIDs []string, filter model.StatisticsFilter) (*model.AchievementStatistics, error) {
+	rows, err := r.FindVerifiedForStatistics(studentIDs, filter)
 	if err != nil {
-		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return nil, err
 	}
 
-	return helper.PaginatedResponse(c, achievements, *pagination)
-}
-
-func (r *AchievementRepository) HandleGetByIDHTTP(c *fiber.Ctx, studentRepo *StudentRepository, lecturerRepo *LecturerRepository) error {
-	id := c.Params("id")
-	userID := c.Locals("userID").(string)
-	roleName := c.Locals("roleName").(string)
-
-	achievement, err := r.HandleGetByID(id, userID, roleName, studentRepo, lecturerRepo)
+	mongoIDs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		mongoIDs = append(mongoIDs, row.MongoAchievementID)
+	}
+	documents, err := r.FindMongoByIDs(mongoIDs, true)
 	if err != nil {
-		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+		return nil, err
 	}
 
-	return helper.SuccessResponse(c, "Achievement retrieved", achievement)
+	return helper.BuildStatistics(rows, documents), nil
 }
 
-func (r *AchievementRepository) HandleUpdateHTTP(c *fiber.Ctx, studentRepo *StudentRepository) error {
-	id := c.Params("id")
-	userID := c.Locals("userID").(string)
-
-	var req model.UpdateAchievementRequest
-	if err := c.BodyParser(&req); err != nil {
-		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
-	}
+// creditedAchievementsSelect lists every verified achievement once per
+// student it is credited to, the owner or a team member who did not decline,
+// with the team size its points may be shared by. Duplicates are left out.
+const creditedAchievementsSelect = `
+	WITH credited AS (
+		SELECT ar.id AS ref_id, ar.student_id
+		FROM achievement_references ar
+		WHERE ar.status = 'verified' AND ar.duplicate_of IS NULL AND ar.deleted_at IS NULL
+		UNION
+		SELECT m.achievement_ref_id, m.student_id
+		FROM achievement_members m
+		JOIN achievement_references ar ON m.achievement_ref_id = ar.id
+		WHERE ar.status = 'verified' AND ar.duplicate_of IS NULL AND ar.deleted_at IS NULL AND m.status <> 'declined'
+	)
+	SELECT ar.id, ar.mongo_achievement_id, ar.team_point_rule,
+	       (SELECT COUNT(*) FROM credited c2 WHERE c2.ref_id = ar.id),
+	       ar.verified_at, s.id, s.student_id, u.full_name, s.program_study, s.academic_year, s.advisor_id
+	FROM credited c
+	JOIN achievement_references ar ON c.ref_id = ar.id
+	JOIN students s ON c.student_id = s.id
+	JOIN users u ON s.user_id = u.id
+`
+
+func scanCreditedRows(rows *sql.Rows) ([]*model.CreditedAchievementRow, error) {
+	defer rows.Close()
 
-	if err := r.HandleUpdate(id, userID, &req, studentRepo); err != nil {
-		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	result := []*model.CreditedAchievementRow{}
+	for rows.Next() {
+		row := &model.CreditedAchievementRow{}
+		err := rows.Scan(
+			&row.AchievementRefID, &row.MongoAchievementID, &row.TeamPointRule, &row.TeamSize,
+			&row.VerifiedAt, &row.StudentID, &row.StudentNumber, &row.FullName,
+			&row.ProgramStudy, &row.AcademicYear, &row.AdvisorID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
 	}
-
-	return helper.SuccessResponse(c, "Achievement updated successfully", nil)
+	return result, rows.Err()
 }
 
-func (r *AchievementRepository) HandleDeleteHTTP(c *fiber.Ctx, studentRepo *StudentRepository) error {
-	id := c.Params("id")
-	userID := c.Locals("userID").(string)
-
-	if err := r.HandleDelete(id, userID, studentRepo); err != nil {
-		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+// FindCreditedForLeaderboard lists every verified achievement once per
+// student it is credited to, narrowed by the filter's program study, cohort
+// and period. Achievements marked as a duplicate of another are left out so
+// a student is not ranked twice for the same result, and expired
+// certifications unless the filter includes them.
+func (r *AchievementRepository) FindCreditedForLeaderboard(filter model.LeaderboardFilter) ([]*model.CreditedAchievementRow, error) {
+	query := creditedAchievementsSelect + " WHERE u.is_active = true"
+	if !filter.IncludeExpired {
+		query += " AND ar.expired_at IS NULL"
+	}
+	var args []interface{}
+	if filter.ProgramStudy != "" {
+		args = append(args, filter.ProgramStudy)
+		query += fmt.Sprintf(" AND s.program_study = $%d", len(args))
+	}
+	if filter.Cohort != "" {
+		args = append(args, filter.Cohort)
+		query += fmt.Sprintf(" AND s.academic_year = $%d", len(args))
+	}
+	if filter.PeriodID != "" {
+		args = append(args, filter.PeriodID)
+		query += fmt.Sprintf(" AND ar.period_id = $%d", len(args))
 	}
 
-	return helper.SuccessResponse(c, "Achievement deleted successfully", nil)
+	rows, err := database.PostgresDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanCreditedRows(rows)
 }
 
-func (r *AchievementRepository) HandleSubmitHTTP(c *fiber.Ctx, studentRepo *StudentRepository) error {
-	id := c.Params("id")
-	userID := c.Locals("userID").(string)
-
-	if err := r.HandleSubmit(id, userID, studentRepo); err != nil {
-		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+// FindCreditedForStudents lists the verified achievements credited to the
+// given students, expired certifications included. Duplicates are left out.
+func (r *AchievementRepository) FindCreditedForStudents(studentIDs []string) ([]*model.CreditedAchievementRow, error) {
+	if len(studentIDs) == 0 {
+		return []*model.CreditedAchievementRow{}, nil
 	}
 
-	return helper.SuccessResponse(c, "Achievement submitted for verification", nil)
+	rows, err := database.PostgresDB.Query(creditedAchievementsSelect+" WHERE c.student_id = ANY($1)", pq.Array(studentIDs))
+	if err != nil {
+		return nil, err
+	}
+	return scanCreditedRows(rows)
 }
 
-func (r *AchievementRepository) HandleVerifyHTTP(c *fiber.Ctx) error {
-	id := c.Params("id")
-	userID := c.Locals("userID").(string)
-
-	var req model.VerifyAchievementRequest
-	if err := c.BodyParser(&req); err != nil {
-		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+// FindVerifiedForStatistics lists verified references with the program
+// study, cohort and advisor of their owner and their academic period
+func (r *AchievementRepository) FindVerifiedForStatistics(studentIDs []string, filter model.StatisticsFilter) ([]*model.VerifiedAchievementRow, error) {
+	query := `
+		SELECT ar.id, ar.mongo_achievement_id, ar.student_id, s.program_study, s.academic_year,
+		       s.advisor_id, u.full_name, p.code, p.name, ar.verified_at
+		FROM achievement_references ar
+		JOIN students s ON ar.student_id = s.id
+		LEFT JOIN lecturers l ON s.advisor_id = l.id
+		LEFT JOIN users u ON l.user_id = u.id
+		LEFT JOIN academic_periods p ON ar.period_id = p.id
+		WHERE ar.status = 'verified' AND ar.duplicate_of IS NULL AND ar.deleted_at IS NULL
+	`
+	if !filter.IncludeExpired {
+		query += " AND ar.expired_at IS NULL"
 	}
-
-	if err := r.HandleVerify(id, userID, &req); err != nil {
-		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	var args []interface{}
+	if len(studentIDs) > 0 {
+		args = append(args, pq.Array(studentIDs))
+		query += fmt.Sprintf(`
+			AND (ar.student_id = ANY($%[1]d) OR ar.id IN (
+				SELECT achievement_ref_id FROM achievement_members
+				WHERE student_id = ANY($%[1]d) AND status <> 'declined'
+			))
+		`, len(args))
 	}
-
-	message := "Achievement verified successfully"
-	if req.Action == "reject" {
-		message = "Achievement rejected"
+	if filter.PeriodID != "" {
+		args = append(args, filter.PeriodID)
+		query += fmt.Sprintf(" AND ar.period_id = $%d", len(args))
 	}
 
-	return helper.SuccessResponse(c, message, nil)
-}
-
-func (r *AchievementRepository) HandleStatisticsHTTP(c *fiber.Ctx, studentRepo *StudentRepository, lecturerRepo *LecturerRepository) error {
-	userID := c.Locals("userID").(string)
-	roleName := c.Locals("roleName").(string)
-
-	stats, err := r.HandleStatistics(userID, roleName, studentRepo, lecturerRepo)
+	rows, err := database.PostgresDB.Query(query, args...)
 	if err != nil {
-		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return nil, err
 	}
+	defer rows.Close()
 
-	return helper.SuccessResponse(c, "Statistics retrieved", stats)
+	result := []*model.VerifiedAchievementRow{}
+	for rows.Next() {
+		row := &model.VerifiedAchievementRow{}
+		err := rows.Scan(
+			&row.AchievementRefID, &row.MongoAchievementID, &row.StudentID, &row.ProgramStudy,
+			&row.AcademicYear, &row.AdvisorID, &row.AdvisorName, &row.PeriodCode, &row.PeriodName,
+			&row.VerifiedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
 }
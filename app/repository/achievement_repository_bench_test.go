@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+)
+
+// benchmarkPageSize matches the default page size of the achievement list.
+const benchmarkPageSize = 10
+
+// seedBenchmarkAchievements connects to the MongoDB named by MONGODB_URI,
+// stores one page of achievements in a scratch database and returns their
+// ids. The benchmark is skipped when no server is reachable.
+func seedBenchmarkAchievements(b *testing.B) []string {
+	b.Helper()
+
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		b.Skip("MONGODB_URI is not set")
+	}
+	dbname := fmt.Sprintf("achievement_bench_%d", time.Now().UnixNano())
+	if err := database.ConnectMongoDB(uri, dbname); err != nil {
+		b.Skipf("mongodb unavailable: %v", err)
+	}
+	b.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.MongoDB.Drop(ctx)
+		database.CloseMongoDB()
+	})
+
+	repo := NewAchievementRepository()
+	ids := make([]string, 0, benchmarkPageSize)
+	for i := 0; i < benchmarkPageSize; i++ {
+		achievement := &model.Achievement{
+			StudentID:       "bench-student",
+			AchievementType: "competition",
+			Title:           fmt.Sprintf("Benchmark achievement %d", i),
+			Description:     "Seeded by the achievement list benchmark",
+			Tags:            []string{"benchmark"},
+			Points:          10,
+		}
+		if err := repo.CreateMongo(achievement); err != nil {
+			b.Fatal(err)
+		}
+		ids = append(ids, achievement.ID.Hex())
+	}
+	return ids
+}
+
+// BenchmarkLoadListPage compares loading the documents of one list page with a
+// single $in query against one FindMongoByID round trip per reference.
+func BenchmarkLoadListPage(b *testing.B) {
+	ids := seedBenchmarkAchievements(b)
+	repo := NewAchievementRepository()
+
+	b.Run("PerReference", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, id := range ids {
+				if _, err := repo.FindMongoByID(id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("Batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			achievements, err := repo.FindMongoByIDs(ids, false)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(achievements) != len(ids) {
+				b.Fatalf("loaded %d of %d achievements", len(achievements), len(ids))
+			}
+		}
+	})
+
+	b.Run("BatchedSummary", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.FindMongoByIDs(ids, true); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
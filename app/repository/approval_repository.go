@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+
+	"github.com/lib/pq"
+)
+
+type ApprovalRepository struct{}
+
+func NewApprovalRepository() *ApprovalRepository {
+	return &ApprovalRepository{}
+}
+
+func (r *ApprovalRepository) CreateChain(chain *model.ApprovalChain) error {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO approval_chains (name, achievement_type, competition_level)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(query, chain.Name, chain.AchievementType, chain.CompetitionLevel).
+		Scan(&chain.ID, &chain.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range chain.Steps {
+		step := &chain.Steps[i]
+		err := tx.QueryRow(
+			"INSERT INTO approval_chain_steps (chain_id, step_order, name, allowed_roles) VALUES ($1, $2, $3, $4) RETURNING id",
+			chain.ID, step.StepOrder, step.Name, pq.Array(step.AllowedRoles),
+		).Scan(&step.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ApprovalRepository) GetChains() ([]*model.ApprovalChain, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT id, name, achievement_type, competition_level, created_at
+		FROM approval_chains
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chains := []*model.ApprovalChain{}
+	for rows.Next() {
+		chain := &model.ApprovalChain{}
+		if err := rows.Scan(&chain.ID, &chain.Name, &chain.AchievementType, &chain.CompetitionLevel, &chain.CreatedAt); err != nil {
+			return nil, err
+		}
+		chains = append(chains, chain)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, chain := range chains {
+		chain.Steps, err = r.getSteps(chain.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return chains, nil
+}
+
+// FindChainFor returns the most specific chain matching the achievement type
+// and competition level, or nil when no chain applies.
+func (r *ApprovalRepository) FindChainFor(achievementType, competitionLevel string) (*model.ApprovalChain, error) {
+	chain := &model.ApprovalChain{}
+	query := `
+		SELECT id, name, achievement_type, competition_level, created_at
+		FROM approval_chains
+		WHERE (achievement_type IS NULL OR LOWER(achievement_type) = LOWER($1))
+		  AND (competition_level IS NULL OR LOWER(competition_level) = LOWER($2))
+		ORDER BY (achievement_type IS NOT NULL)::int + (competition_level IS NOT NULL)::int DESC, created_at ASC
+		LIMIT 1
+	`
+	err := database.PostgresDB.QueryRow(query, achievementType, competitionLevel).Scan(
+		&chain.ID, &chain.Name, &chain.AchievementType, &chain.CompetitionLevel, &chain.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	chain.Steps, err = r.getSteps(chain.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return chain, nil
+}
+
+func (r *ApprovalRepository) getSteps(chainID string) ([]model.ApprovalStep, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT id, step_order, name, allowed_roles
+		FROM approval_chain_steps
+		WHERE chain_id = $1
+		ORDER BY step_order ASC
+	`, chainID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	steps := []model.ApprovalStep{}
+	for rows.Next() {
+		var step model.ApprovalStep
+		if err := rows.Scan(&step.ID, &step.StepOrder, &step.Name, pq.Array(&step.AllowedRoles)); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
+func (r *ApprovalRepository) DeleteChain(id string) error {
+	result, err := database.PostgresDB.Exec("DELETE FROM approval_chains WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("approval chain not found")
+	}
+
+	return nil
+}
+
+// insertApproval records the decision taken on an approval step
+func insertApproval(db execer, approval *model.AchievementApproval) error {
+	query := `
+		INSERT INTO achievement_approvals (achievement_ref_id, step_order, step_name, approver_id, action, note, submitted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	return db.QueryRow(
+		query,
+		approval.AchievementRefID, approval.StepOrder, approval.StepName, approval.ApproverID,
+		approval.Action, approval.Note, approval.SubmittedAt,
+	).Scan(&approval.ID, &approval.CreatedAt)
+}
+
+func (r *ApprovalRepository) FindApprovalsByReference(achievementRefID string) ([]*model.AchievementApproval, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT a.id, a.achievement_ref_id, a.step_order, a.step_name, a.approver_id, u.full_name,
+		       a.action, a.note, a.submitted_at, a.created_at
+		FROM achievement_approvals a
+		JOIN users u ON a.approver_id = u.id
+		WHERE a.achievement_ref_id = $1
+		ORDER BY a.created_at ASC
+	`, achievementRefID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []*model.AchievementApproval{}
+	for rows.Next() {
+		approval := &model.AchievementApproval{}
+		err := rows.Scan(
+			&approval.ID, &approval.AchievementRefID, &approval.StepOrder, &approval.StepName,
+			&approval.ApproverID, &approval.ApproverName, &approval.Action, &approval.Note,
+			&approval.SubmittedAt, &approval.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+	return approvals, rows.Err()
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+)
+
+type CommentRepository struct{}
+
+func NewCommentRepository() *CommentRepository {
+	return &CommentRepository{}
+}
+
+const commentSelect = `
+	SELECT c.id, c.achievement_ref_id, c.parent_id, c.author_id, u.full_name, COALESCE(r.name, ''),
+	       c.kind, c.body, c.revision, c.created_at, c.updated_at, c.edited_at, c.deleted_at
+	FROM achievement_comments c
+	JOIN users u ON c.author_id = u.id
+	LEFT JOIN roles r ON u.role_id = r.id
+`
+
+func scanComment(scanner interface{ Scan(...interface{}) error }) (*model.AchievementComment, error) {
+	comment := &model.AchievementComment{}
+	err := scanner.Scan(
+		&comment.ID, &comment.AchievementRefID, &comment.ParentID, &comment.AuthorID,
+		&comment.AuthorName, &comment.AuthorRole, &comment.Kind, &comment.Body, &comment.Revision,
+		&comment.CreatedAt, &comment.UpdatedAt, &comment.EditedAt, &comment.DeletedAt,
+	)
+	return comment, err
+}
+
+func (r *CommentRepository) Create(comment *model.AchievementComment) error {
+	return insertComment(database.PostgresDB, comment)
+}
+
+func insertComment(db execer, comment *model.AchievementComment) error {
+	query := `
+		INSERT INTO achievement_comments (achievement_ref_id, parent_id, author_id, kind, body, revision)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	return db.QueryRow(
+		query,
+		comment.AchievementRefID, comment.ParentID, comment.AuthorID, comment.Kind, comment.Body, comment.Revision,
+	).Scan(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt)
+}
+
+func (r *CommentRepository) FindByID(id string) (*model.AchievementComment, error) {
+	row := database.PostgresDB.QueryRow(commentSelect+" WHERE c.id = $1", id)
+	comment, err := scanComment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return comment, err
+}
+
+// FindByAchievementID returns the whole thread in posting order. Deleted
+// comments are kept with an empty body so replies stay attached.
+func (r *CommentRepository) FindByAchievementID(achievementRefID string) ([]*model.AchievementComment, error) {
+	rows, err := database.PostgresDB.Query(
+		commentSelect+" WHERE c.achievement_ref_id = $1 ORDER BY c.created_at ASC",
+		achievementRefID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*model.AchievementComment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		if comment.DeletedAt != nil {
+			comment.Body = ""
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+func (r *CommentRepository) UpdateBody(id, body string) error {
+	now := time.Now()
+	query := `
+		UPDATE achievement_comments
+		SET body = $1, edited_at = $2, updated_at = $2
+		WHERE id = $3 AND deleted_at IS NULL
+	`
+	result, err := database.PostgresDB.Exec(query, body, now, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("comment not found or already deleted")
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) SoftDelete(id string) error {
+	query := `
+		UPDATE achievement_comments
+		SET deleted_at = $1, updated_at = $1
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+	result, err := database.PostgresDB.Exec(query, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("comment not found or already deleted")
+	}
+
+	return nil
+}
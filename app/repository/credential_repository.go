@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+)
+
+type CredentialRepository struct{}
+
+func NewCredentialRepository() *CredentialRepository {
+	return &CredentialRepository{}
+}
+
+// FindOrCreateIssuerKey returns the signing key, storing the generated one
+// when none exists yet. When two instances race, the first stored key wins.
+func (r *CredentialRepository) FindOrCreateIssuerKey(generate func() (*model.IssuerKey, error)) (*model.IssuerKey, error) {
+	key, err := r.findIssuerKey()
+	if err != nil || key != nil {
+		return key, err
+	}
+
+	generated, err := generate()
+	if err != nil {
+		return nil, err
+	}
+	_, err = database.PostgresDB.Exec(`
+		INSERT INTO credential_issuer_keys (id, public_key, private_key)
+		VALUES (1, $1, $2)
+		ON CONFLICT (id) DO NOTHING
+	`, generated.PublicKey, generated.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.findIssuerKey()
+}
+
+func (r *CredentialRepository) findIssuerKey() (*model.IssuerKey, error) {
+	key := &model.IssuerKey{}
+	err := database.PostgresDB.QueryRow(
+		"SELECT id, public_key, private_key, created_at FROM credential_issuer_keys WHERE id = 1",
+	).Scan(&key.ID, &key.PublicKey, &key.PrivateKey, &key.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+// UpdateIssuerPrivateKey replaces the stored private key, e.g. when it is
+// encrypted with a new secret
+func (r *CredentialRepository) UpdateIssuerPrivateKey(privateKey []byte) error {
+	_, err := database.PostgresDB.Exec("UPDATE credential_issuer_keys SET private_key = $1 WHERE id = 1", privateKey)
+	return err
+}
+
+// NextStatusIndex reserves an entry in the status list
+func (r *CredentialRepository) NextStatusIndex() (int, error) {
+	var index int
+	err := database.PostgresDB.QueryRow("SELECT nextval('credential_status_index_seq') - 1").Scan(&index)
+	return index, err
+}
+
+// Create stores the credential. It reports false when the achievement
+// already has an active credential, e.g. one issued concurrently.
+func (r *CredentialRepository) Create(credential *model.AchievementCredential) (bool, error) {
+	document, err := json.Marshal(credential.Credential)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := database.PostgresDB.Exec(`
+		INSERT INTO achievement_credentials (id, achievement_ref_id, status_index, credential, issued_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (achievement_ref_id) WHERE revoked_at IS NULL DO NOTHING
+	`, credential.ID, credential.AchievementRefID, credential.StatusIndex, document, credential.IssuedAt)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// FindActiveByReference returns the unrevoked credential of the achievement
+func (r *CredentialRepository) FindActiveByReference(achievementRefID string) (*model.AchievementCredential, error) {
+	credential := &model.AchievementCredential{}
+	var document []byte
+	err := database.PostgresDB.QueryRow(`
+		SELECT id, achievement_ref_id, status_index, credential, issued_at, revoked_at
+		FROM achievement_credentials
+		WHERE achievement_ref_id = $1 AND revoked_at IS NULL
+		ORDER BY issued_at DESC
+		LIMIT 1
+	`, achievementRefID).Scan(
+		&credential.ID, &credential.AchievementRefID, &credential.StatusIndex,
+		&document, &credential.IssuedAt, &credential.RevokedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(document, &credential.Credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// FindRevokedIndexes lists the status entries to set: credentials revoked
+// explicitly and credentials whose achievement is no longer verified
+func (r *CredentialRepository) FindRevokedIndexes() ([]int, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT c.status_index
+		FROM achievement_credentials c
+		LEFT JOIN achievement_references ar ON c.achievement_ref_id = ar.id
+		WHERE c.revoked_at IS NOT NULL OR ar.id IS NULL OR ar.status <> 'verified'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := []int{}
+	for rows.Next() {
+		var index int
+		if err := rows.Scan(&index); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, rows.Err()
+}
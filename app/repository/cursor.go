@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"fmt"
+
+	"projek_uas/app/model"
+)
+
+// keysetCondition returns the condition and ordering that continue a listing
+// ordered by (created_at, id), newest first, from the cursor. Backward
+// cursors read newer rows in ascending order; callers reverse them.
+func keysetCondition(cursor *model.Cursor, createdAtColumn, idColumn string, argIndex int) (string, string, []interface{}) {
+	if cursor == nil {
+		return "", fmt.Sprintf(" ORDER BY %s DESC, %s DESC", createdAtColumn, idColumn), nil
+	}
+
+	operator, direction := "<", "DESC"
+	if cursor.Backward {
+		operator, direction = ">", "ASC"
+	}
+
+	condition := fmt.Sprintf(" AND (%s, %s) %s ($%d::timestamp, $%d::uuid)", createdAtColumn, idColumn, operator, argIndex, argIndex+1)
+	order := fmt.Sprintf(" ORDER BY %s %s, %s %s", createdAtColumn, direction, idColumn, direction)
+	return condition, order, []interface{}{cursor.CreatedAt, cursor.ID}
+}
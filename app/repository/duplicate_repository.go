@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"projek_uas/app/model"
+	"projek_uas/database"
+
+	"github.com/lib/pq"
+)
+
+type DuplicateRepository struct{}
+
+func NewDuplicateRepository() *DuplicateRepository {
+	return &DuplicateRepository{}
+}
+
+// ReplaceFlags stores the latest duplicate check of an achievement, dropping
+// the results of earlier checks
+func (r *DuplicateRepository) ReplaceFlags(achievementRefID string, candidates []*model.DuplicateCandidate) error {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM achievement_duplicate_flags WHERE achievement_ref_id = $1", achievementRefID); err != nil {
+		return err
+	}
+
+	for _, candidate := range candidates {
+		err := tx.QueryRow(`
+			INSERT INTO achievement_duplicate_flags (achievement_ref_id, candidate_ref_id, score, reasons)
+			VALUES ($1, $2, $3, $4)
+			RETURNING detected_at
+		`, achievementRefID, candidate.AchievementID, candidate.Score, pq.Array(candidate.Reasons)).Scan(&candidate.DetectedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *DuplicateRepository) FindFlags(achievementRefID string) ([]*model.DuplicateCandidate, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT f.candidate_ref_id, ar.mongo_achievement_id, ar.student_id, ar.status, f.score, f.reasons, f.detected_at
+		FROM achievement_duplicate_flags f
+		JOIN achievement_references ar ON f.candidate_ref_id = ar.id
+		WHERE f.achievement_ref_id = $1 AND ar.deleted_at IS NULL
+		ORDER BY f.score DESC
+	`, achievementRefID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []*model.DuplicateCandidate{}
+	for rows.Next() {
+		candidate := &model.DuplicateCandidate{}
+		err := rows.Scan(
+			&candidate.AchievementID, &candidate.MongoID, &candidate.StudentID, &candidate.Status,
+			&candidate.Score, pq.Array(&candidate.Reasons), &candidate.DetectedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+
+	"github.com/lib/pq"
+)
+
+type GraduationRepository struct{}
+
+func NewGraduationRepository() *GraduationRepository {
+	return &GraduationRepository{}
+}
+
+const requirementColumns = "id, program_study, cohort, min_points, created_at, updated_at"
+
+func scanRequirement(scanner interface{ Scan(...interface{}) error }) (*model.GraduationRequirement, error) {
+	requirement := &model.GraduationRequirement{Categories: []model.RequirementCategory{}}
+	err := scanner.Scan(
+		&requirement.ID, &requirement.ProgramStudy, &requirement.Cohort, &requirement.MinPoints,
+		&requirement.CreatedAt, &requirement.UpdatedAt,
+	)
+	return requirement, err
+}
+
+func (r *GraduationRepository) Create(requirement *model.GraduationRequirement) error {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO graduation_requirements (program_study, cohort, min_points)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, requirement.ProgramStudy, requirement.Cohort, requirement.MinPoints).
+		Scan(&requirement.ID, &requirement.CreatedAt, &requirement.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertRequirementCategories(tx, requirement); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces the requirement's fields and category minimums
+func (r *GraduationRepository) Update(requirement *model.GraduationRequirement) error {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	requirement.UpdatedAt = time.Now()
+	result, err := tx.Exec(`
+		UPDATE graduation_requirements
+		SET program_study = $1, cohort = $2, min_points = $3, updated_at = $4
+		WHERE id = $5
+	`, requirement.ProgramStudy, requirement.Cohort, requirement.MinPoints, requirement.UpdatedAt, requirement.ID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("graduation requirement not found")
+	}
+
+	if _, err := tx.Exec("DELETE FROM graduation_requirement_categories WHERE requirement_id = $1", requirement.ID); err != nil {
+		return err
+	}
+	if err := insertRequirementCategories(tx, requirement); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertRequirementCategories(tx *sql.Tx, requirement *model.GraduationRequirement) error {
+	for _, category := range requirement.Categories {
+		_, err := tx.Exec(
+			"INSERT INTO graduation_requirement_categories (requirement_id, category, min_points) VALUES ($1, $2, $3)",
+			requirement.ID, category.Category, category.MinPoints,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *GraduationRepository) Delete(id string) error {
+	result, err := database.PostgresDB.Exec("DELETE FROM graduation_requirements WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("graduation requirement not found")
+	}
+
+	return nil
+}
+
+func (r *GraduationRepository) FindByID(id string) (*model.GraduationRequirement, error) {
+	requirement, err := scanRequirement(database.PostgresDB.QueryRow(
+		"SELECT "+requirementColumns+" FROM graduation_requirements WHERE id = $1", id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.attachCategories([]*model.GraduationRequirement{requirement}); err != nil {
+		return nil, err
+	}
+	return requirement, nil
+}
+
+// FindAll lists every requirement ordered by program study, the program
+// study default before the cohort specific ones
+func (r *GraduationRepository) FindAll() ([]*model.GraduationRequirement, error) {
+	rows, err := database.PostgresDB.Query(
+		"SELECT " + requirementColumns + " FROM graduation_requirements ORDER BY program_study ASC, cohort ASC",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requirements := []*model.GraduationRequirement{}
+	for rows.Next() {
+		requirement, err := scanRequirement(rows)
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, requirement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachCategories(requirements); err != nil {
+		return nil, err
+	}
+	return requirements, nil
+}
+
+// attachCategories loads the category minimums of the requirements with one
+// query
+func (r *GraduationRepository) attachCategories(requirements []*model.GraduationRequirement) error {
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*model.GraduationRequirement, len(requirements))
+	ids := make([]string, 0, len(requirements))
+	for _, requirement := range requirements {
+		byID[requirement.ID] = requirement
+		ids = append(ids, requirement.ID)
+	}
+
+	rows, err := database.PostgresDB.Query(`
+		SELECT requirement_id, category, min_points
+		FROM graduation_requirement_categories
+		WHERE requirement_id = ANY($1)
+		ORDER BY category ASC
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var category model.RequirementCategory
+		if err := rows.Scan(&id, &category.Category, &category.MinPoints); err != nil {
+			return err
+		}
+		requirement := byID[id]
+		requirement.Categories = append(requirement.Categories, category)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+
+	"github.com/lib/pq"
+)
+
+type MemberRepository struct{}
+
+func NewMemberRepository() *MemberRepository {
+	return &MemberRepository{}
+}
+
+// replaceMembers sets the team of an achievement inside the caller's
+// transaction. Members that stay in the team keep their confirmation,
+// members no longer listed are removed.
+func replaceMembers(tx *sql.Tx, achievementRefID string, members []*model.AchievementMember) error {
+	studentIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		studentIDs = append(studentIDs, member.StudentID)
+	}
+
+	_, err := tx.Exec(
+		"DELETE FROM achievement_members WHERE achievement_ref_id = $1 AND NOT (student_id = ANY($2))",
+		achievementRefID, pq.Array(studentIDs),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		_, err := tx.Exec(`
+			INSERT INTO achievement_members (achievement_ref_id, student_id, role, status, responded_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (achievement_ref_id, student_id) DO UPDATE SET role = EXCLUDED.role
+		`, achievementRefID, member.StudentID, member.Role, member.Status, member.RespondedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemberRepository) FindByReference(achievementRefID string) ([]*model.AchievementMember, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT m.achievement_ref_id, m.student_id, s.student_id, u.full_name,
+		       m.role, m.status, m.responded_at, m.created_at
+		FROM achievement_members m
+		JOIN students s ON m.student_id = s.id
+		JOIN users u ON s.user_id = u.id
+		WHERE m.achievement_ref_id = $1
+		ORDER BY m.role = 'leader' DESC, m.created_at ASC
+	`, achievementRefID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*model.AchievementMember{}
+	for rows.Next() {
+		member := &model.AchievementMember{}
+		err := rows.Scan(
+			&member.AchievementRefID, &member.StudentID, &member.StudentNumber, &member.FullName,
+			&member.Role, &member.Status, &member.RespondedAt, &member.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (r *MemberRepository) FindMember(achievementRefID, studentID string) (*model.AchievementMember, error) {
+	member := &model.AchievementMember{}
+	err := database.PostgresDB.QueryRow(`
+		SELECT achievement_ref_id, student_id, role, status, responded_at, created_at
+		FROM achievement_members
+		WHERE achievement_ref_id = $1 AND student_id = $2
+	`, achievementRefID, studentID).Scan(
+		&member.AchievementRefID, &member.StudentID, &member.Role, &member.Status,
+		&member.RespondedAt, &member.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return member, err
+}
+
+// RespondToInvitation records a pending member's confirmation or refusal
+func (r *MemberRepository) RespondToInvitation(achievementRefID, studentID, status string) error {
+	result, err := database.PostgresDB.Exec(`
+		UPDATE achievement_members
+		SET status = $1, responded_at = $2
+		WHERE achievement_ref_id = $3 AND student_id = $4 AND status = 'pending'
+	`, status, time.Now(), achievementRefID, studentID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("no pending invitation for this achievement")
+	}
+
+	return nil
+}
+
+// InvolvesAnyStudent reports whether the achievement is owned by, or has a
+// non-declined member among, the given students
+func (r *MemberRepository) InvolvesAnyStudent(achievementRefID, ownerID string, studentIDs []string) (bool, error) {
+	for _, id := range studentIDs {
+		if id == ownerID {
+			return true, nil
+		}
+	}
+	if len(studentIDs) == 0 {
+		return false, nil
+	}
+
+	var exists bool
+	err := database.PostgresDB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM achievement_members
+			WHERE achievement_ref_id = $1 AND student_id = ANY($2) AND status <> 'declined'
+		)
+	`, achievementRefID, pq.Array(studentIDs)).Scan(&exists)
+	return exists, err
+}
+
+func (r *MemberRepository) CountPending(achievementRefID string) (int, error) {
+	var count int
+	err := database.PostgresDB.QueryRow(
+		"SELECT COUNT(*) FROM achievement_members WHERE achievement_ref_id = $1 AND status = 'pending'",
+		achievementRefID,
+	).Scan(&count)
+	return count, err
+}
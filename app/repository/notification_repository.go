@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+)
+
+type NotificationRepository struct{}
+
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{}
+}
+
+func (r *NotificationRepository) Create(notification *model.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, type, title, message, achievement_ref_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return database.PostgresDB.QueryRow(
+		query,
+		notification.UserID, notification.Type, notification.Title, notification.Message, notification.AchievementRefID,
+	).Scan(&notification.ID, &notification.CreatedAt)
+}
+
+func (r *NotificationRepository) GetByUser(userID string, unreadOnly bool, limit, offset int) ([]*model.Notification, int64, error) {
+	filter := "WHERE user_id = $1"
+	if unreadOnly {
+		filter += " AND read_at IS NULL"
+	}
+
+	query := `
+		SELECT id, user_id, type, title, message, achievement_ref_id, read_at, created_at
+		FROM notifications
+	` + filter + `
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := database.PostgresDB.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	notifications := []*model.Notification{}
+	for rows.Next() {
+		n := &model.Notification{}
+		err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Message, &n.AchievementRefID, &n.ReadAt, &n.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	var total int64
+	err = database.PostgresDB.QueryRow("SELECT COUNT(*) FROM notifications "+filter, userID).Scan(&total)
+	return notifications, total, err
+}
+
+func (r *NotificationRepository) MarkRead(id, userID string) error {
+	query := `
+		UPDATE notifications
+		SET read_at = $1
+		WHERE id = $2 AND user_id = $3 AND read_at IS NULL
+	`
+	result, err := database.PostgresDB.Exec(query, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("notification not found or already read")
+	}
+
+	return nil
+}
+
+func (r *NotificationRepository) MarkAllRead(userID string) error {
+	_, err := database.PostgresDB.Exec(
+		"UPDATE notifications SET read_at = $1 WHERE user_id = $2 AND read_at IS NULL",
+		time.Now(), userID,
+	)
+	return err
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+)
+
+type OutboxRepository struct{}
+
+func NewOutboxRepository() *OutboxRepository {
+	return &OutboxRepository{}
+}
+
+// insertOutboxEvent records the event inside the caller's transaction
+func insertOutboxEvent(tx *sql.Tx, event *model.OutboxEvent) error {
+	// The caller applies the event right after committing, the relay only
+	// picks it up if that did not happen within a minute
+	query := `
+		INSERT INTO achievement_outbox (achievement_ref_id, mongo_achievement_id, operation, payload, next_attempt_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + INTERVAL '1 minute')
+		RETURNING id, status, next_attempt_at, created_at
+	`
+	var payload interface{}
+	if len(event.Payload) > 0 {
+		payload = []byte(event.Payload)
+	}
+	return tx.QueryRow(query, event.AchievementRefID, event.MongoAchievementID, event.Operation, payload).
+		Scan(&event.ID, &event.Status, &event.NextAttemptAt, &event.CreatedAt)
+}
+
+const outboxColumns = `
+	id, achievement_ref_id, mongo_achievement_id, operation, payload, status,
+	attempts, last_error, next_attempt_at, created_at, processed_at
+`
+
+func scanOutboxEvent(scanner interface{ Scan(...interface{}) error }) (*model.OutboxEvent, error) {
+	event := &model.OutboxEvent{}
+	var payload []byte
+	err := scanner.Scan(
+		&event.ID, &event.AchievementRefID, &event.MongoAchievementID, &event.Operation, &payload,
+		&event.Status, &event.Attempts, &event.LastError, &event.NextAttemptAt, &event.CreatedAt,
+		&event.ProcessedAt,
+	)
+	event.Payload = payload
+	return event, err
+}
+
+// FindDue returns pending events whose next attempt is due, oldest first
+func (r *OutboxRepository) FindDue(limit int) ([]*model.OutboxEvent, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT `+outboxColumns+`
+		FROM achievement_outbox
+		WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY created_at ASC
+		LIMIT $2
+	`, time.Now(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*model.OutboxEvent{}
+	for rows.Next() {
+		event, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// MarkProcessed closes a pending event with a final status. It reports false
+// when the event was already closed by another worker.
+func (r *OutboxRepository) MarkProcessed(id, status string, lastError *string) (bool, error) {
+	result, err := database.PostgresDB.Exec(`
+		UPDATE achievement_outbox
+		SET status = $1, last_error = $2, processed_at = $3
+		WHERE id = $4 AND status = 'pending'
+	`, status, lastError, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// ScheduleRetry records a failed attempt and when to try again
+func (r *OutboxRepository) ScheduleRetry(id string, lastError string, nextAttemptAt time.Time) error {
+	_, err := database.PostgresDB.Exec(`
+		UPDATE achievement_outbox
+		SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2
+		WHERE id = $3 AND status = 'pending'
+	`, lastError, nextAttemptAt, id)
+	return err
+}
+
+// HasPendingCreate reports whether the document of the reference is still
+// waiting to be written to MongoDB
+func (r *OutboxRepository) HasPendingCreate(achievementRefID string) (bool, error) {
+	var pending bool
+	err := database.PostgresDB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM achievement_outbox
+			WHERE achievement_ref_id = $1 AND operation = $2 AND status = 'pending'
+		)
+	`, achievementRefID, model.OutboxCreateDocument).Scan(&pending)
+	return pending, err
+}
+
+// OpenOperations maps document ids to the operation of their pending or
+// failed outbox event, i.e. changes that have not reached MongoDB
+func (r *OutboxRepository) OpenOperations() (map[string]string, error) {
+	rows, err := database.PostgresDB.Query(`
+		SELECT mongo_achievement_id, operation
+		FROM achievement_outbox
+		WHERE status IN ('pending', 'failed')
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	operations := make(map[string]string)
+	for rows.Next() {
+		var mongoID, operation string
+		if err := rows.Scan(&mongoID, &operation); err != nil {
+			return nil, err
+		}
+		operations[mongoID] = operation
+	}
+	return operations, rows.Err()
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+)
+
+type PeriodRepository struct{}
+
+func NewPeriodRepository() *PeriodRepository {
+	return &PeriodRepository{}
+}
+
+const periodColumns = "id, code, name, start_date, end_date, is_active, created_at, updated_at"
+
+func scanPeriod(scanner interface{ Scan(...interface{}) error }) (*model.AcademicPeriod, error) {
+	period := &model.AcademicPeriod{}
+	err := scanner.Scan(
+		&period.ID, &period.Code, &period.Name, &period.StartDate, &period.EndDate,
+		&period.IsActive, &period.CreatedAt, &period.UpdatedAt,
+	)
+	return period, err
+}
+
+func (r *PeriodRepository) findOne(condition string, args ...interface{}) (*model.AcademicPeriod, error) {
+	period, err := scanPeriod(database.PostgresDB.QueryRow("SELECT "+periodColumns+" FROM academic_periods WHERE "+condition, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return period, err
+}
+
+// Create stores the period. An active period deactivates the others.
+func (r *PeriodRepository) Create(period *model.AcademicPeriod) error {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if period.IsActive {
+		if _, err := tx.Exec("UPDATE academic_periods SET is_active = false, updated_at = $1 WHERE is_active", time.Now()); err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO academic_periods (code, name, start_date, end_date, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`, period.Code, period.Name, period.StartDate, period.EndDate, period.IsActive).
+		Scan(&period.ID, &period.CreatedAt, &period.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces the period's fields. An active period deactivates the
+// others.
+func (r *PeriodRepository) Update(period *model.AcademicPeriod) error {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	period.UpdatedAt = time.Now()
+	if period.IsActive {
+		_, err := tx.Exec("UPDATE academic_periods SET is_active = false, updated_at = $1 WHERE is_active AND id <> $2", period.UpdatedAt, period.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE academic_periods
+		SET code = $1, name = $2, start_date = $3, end_date = $4, is_active = $5, updated_at = $6
+		WHERE id = $7
+	`, period.Code, period.Name, period.StartDate, period.EndDate, period.IsActive, period.UpdatedAt, period.ID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("academic period not found")
+	}
+
+	return tx.Commit()
+}
+
+func (r *PeriodRepository) Delete(id string) error {
+	result, err := database.PostgresDB.Exec("DELETE FROM academic_periods WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("academic period not found")
+	}
+
+	return nil
+}
+
+func (r *PeriodRepository) FindByID(id string) (*model.AcademicPeriod, error) {
+	return r.findOne("id = $1", id)
+}
+
+func (r *PeriodRepository) FindByCode(code string) (*model.AcademicPeriod, error) {
+	return r.findOne("code = $1", code)
+}
+
+func (r *PeriodRepository) FindActive() (*model.AcademicPeriod, error) {
+	return r.findOne("is_active")
+}
+
+// FindForDate returns the period whose range includes the date
+func (r *PeriodRepository) FindForDate(date time.Time) (*model.AcademicPeriod, error) {
+	return r.findOne("$1::date BETWEEN start_date AND end_date", date.Format("2006-01-02"))
+}
+
+// FindOverlapping returns a period other than excludeID whose range
+// overlaps the given one
+func (r *PeriodRepository) FindOverlapping(start, end time.Time, excludeID string) (*model.AcademicPeriod, error) {
+	return r.findOne("start_date <= $2 AND end_date >= $1 AND id::text <> $3 LIMIT 1", start, end, excludeID)
+}
+
+// FindAll lists the periods, most recent first
+func (r *PeriodRepository) FindAll() ([]*model.AcademicPeriod, error) {
+	rows, err := database.PostgresDB.Query("SELECT " + periodColumns + " FROM academic_periods ORDER BY start_date DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []*model.AcademicPeriod{}
+	for rows.Next() {
+		period, err := scanPeriod(rows)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, period)
+	}
+	return periods, rows.Err()
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RevisionRepository struct{}
+
+func NewRevisionRepository() *RevisionRepository {
+	return &RevisionRepository{}
+}
+
+// revisionCreateAttempts bounds how often Create retries when a concurrent
+// writer took the revision number it computed
+const revisionCreateAttempts = 3
+
+// Create appends a new revision. Revisions are never updated, the
+// unique index on (achievementId, revision) rejects concurrent writers that
+// computed the same revision number; Create then retries with the next one.
+func (r *RevisionRepository) Create(revision *model.AchievementRevision) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var err error
+	for attempt := 0; attempt < revisionCreateAttempts; attempt++ {
+		var latest int
+		latest, err = r.LatestNumber(revision.AchievementID)
+		if err != nil {
+			return err
+		}
+
+		revision.Revision = latest + 1
+		revision.CreatedAt = time.Now()
+
+		var result *mongo.InsertOneResult
+		result, err = database.MongoDB.Collection("achievement_revisions").InsertOne(ctx, revision)
+		if err == nil {
+			revision.ID = result.InsertedID.(primitive.ObjectID)
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (r *RevisionRepository) LatestNumber(achievementID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var latest model.AchievementRevision
+	opts := options.FindOne().SetSort(bson.M{"revision": -1}).SetProjection(bson.M{"revision": 1})
+	err := database.MongoDB.Collection("achievement_revisions").
+		FindOne(ctx, bson.M{"achievementId": achievementID}, opts).Decode(&latest)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return latest.Revision, nil
+}
+
+// DeleteByAchievementID removes the whole history of a document. It is only
+// used when the achievement itself is purged.
+func (r *RevisionRepository) DeleteByAchievementID(achievementID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := database.MongoDB.Collection("achievement_revisions").
+		DeleteMany(ctx, bson.M{"achievementId": achievementID})
+	return err
+}
+
+func (r *RevisionRepository) FindByAchievementID(achievementID string) ([]*model.AchievementRevision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"revision": 1})
+	cursor, err := database.MongoDB.Collection("achievement_revisions").
+		Find(ctx, bson.M{"achievementId": achievementID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []*model.AchievementRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (r *RevisionRepository) FindByNumber(achievementID string, revision int) (*model.AchievementRevision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var result model.AchievementRevision
+	err := database.MongoDB.Collection("achievement_revisions").
+		FindOne(ctx, bson.M{"achievementId": achievementID, "revision": revision}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// FindLatest returns the most recent revision of an achievement, or nil when
+// it has none
+func (r *RevisionRepository) FindLatest(achievementID string) (*model.AchievementRevision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var revision model.AchievementRevision
+	opts := options.FindOne().SetSort(bson.M{"revision": -1})
+	err := database.MongoDB.Collection("achievement_revisions").
+		FindOne(ctx, bson.M{"achievementId": achievementID}, opts).Decode(&revision)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+)
+
+type SKPIRepository struct{}
+
+func NewSKPIRepository() *SKPIRepository {
+	return &SKPIRepository{}
+}
+
+// CreateSnapshot stores the finalized document. A student has at most one
+// snapshot and it is never updated afterwards.
+func (r *SKPIRepository) CreateSnapshot(snapshot *model.SKPISnapshot) error {
+	document, err := json.Marshal(snapshot.Document)
+	if err != nil {
+		return err
+	}
+
+	err = database.PostgresDB.QueryRow(`
+		INSERT INTO skpi_snapshots (student_id, document, finalized_by, finalized_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (student_id) DO NOTHING
+		RETURNING id
+	`, snapshot.StudentID, document, snapshot.FinalizedBy, snapshot.FinalizedAt).Scan(&snapshot.ID)
+	if err == sql.ErrNoRows {
+		return errors.New("skpi has already been finalized")
+	}
+	return err
+}
+
+func (r *SKPIRepository) FindSnapshot(studentID string) (*model.SKPISnapshot, error) {
+	snapshot := &model.SKPISnapshot{}
+	var document []byte
+	err := database.PostgresDB.QueryRow(`
+		SELECT id, student_id, document, finalized_by, finalized_at
+		FROM skpi_snapshots WHERE student_id = $1
+	`, studentID).Scan(&snapshot.ID, &snapshot.StudentID, &document, &snapshot.FinalizedBy, &snapshot.FinalizedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(document, &snapshot.Document); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+)
+
+type SLARepository struct{}
+
+func NewSLARepository() *SLARepository {
+	return &SLARepository{}
+}
+
+const staleSubmissionSelect = `
+	SELECT ar.id, s.student_id, u.full_name, l.user_id, ar.submitted_at, ar.approval_step, ar.approval_steps
+	FROM achievement_references ar
+	JOIN students s ON ar.student_id = s.id
+	JOIN users u ON s.user_id = u.id
+	LEFT JOIN lecturers l ON s.advisor_id = l.id
+	WHERE ar.status = 'submitted' AND ar.submitted_at < $1
+`
+
+// FindReminderCandidates returns submissions older than the given time whose
+// current approver has not been reminded yet
+func (r *SLARepository) FindReminderCandidates(submittedBefore time.Time) ([]*model.StaleSubmission, error) {
+	return r.findStale(staleSubmissionSelect+" AND ar.reminder_sent_at IS NULL ORDER BY ar.submitted_at ASC", submittedBefore)
+}
+
+// FindEscalationCandidates returns submissions older than the given time that
+// have not been escalated yet
+func (r *SLARepository) FindEscalationCandidates(submittedBefore time.Time) ([]*model.StaleSubmission, error) {
+	return r.findStale(staleSubmissionSelect+" AND ar.escalated_at IS NULL ORDER BY ar.submitted_at ASC", submittedBefore)
+}
+
+func (r *SLARepository) findStale(query string, submittedBefore time.Time) ([]*model.StaleSubmission, error) {
+	rows, err := database.PostgresDB.Query(query, submittedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var submissions []*model.StaleSubmission
+	for rows.Next() {
+		sub := &model.StaleSubmission{}
+		var approvalSteps []byte
+		err := rows.Scan(
+			&sub.AchievementRefID, &sub.StudentNumber, &sub.StudentName, &sub.AdvisorUserID, &sub.SubmittedAt,
+			&sub.ApprovalStep, &approvalSteps,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if approvalSteps != nil {
+			if err := json.Unmarshal(approvalSteps, &sub.ApprovalSteps); err != nil {
+				return nil, err
+			}
+		}
+		submissions = append(submissions, sub)
+	}
+	return submissions, rows.Err()
+}
+
+func (r *SLARepository) MarkReminderSent(achievementRefID string) error {
+	_, err := database.PostgresDB.Exec(
+		"UPDATE achievement_references SET reminder_sent_at = $1 WHERE id = $2",
+		time.Now(), achievementRefID,
+	)
+	return err
+}
+
+func (r *SLARepository) MarkEscalated(achievementRefID string) error {
+	_, err := database.PostgresDB.Exec(
+		"UPDATE achievement_references SET escalated_at = $1 WHERE id = $2",
+		time.Now(), achievementRefID,
+	)
+	return err
+}
+
+// GetReviewLatency reports per advisor how long first-step reviews took and
+// how many advisee submissions are still waiting. Submissions older than
+// overdueBefore count as overdue. An empty lecturerID returns every advisor.
+func (r *SLARepository) GetReviewLatency(overdueBefore time.Time, lecturerID string) ([]*model.AdvisorReviewLatency, error) {
+	query := `
+		WITH decisions AS (
+			SELECT approver_id, EXTRACT(EPOCH FROM (created_at - submitted_at)) / 3600 AS hours
+			FROM achievement_approvals
+			WHERE submitted_at IS NOT NULL AND step_order = 1
+		), pending AS (
+			SELECT s.advisor_id,
+			       COUNT(*) AS pending,
+			       COUNT(*) FILTER (WHERE ar.submitted_at < $1) AS overdue,
+			       MAX(EXTRACT(EPOCH FROM (NOW() - ar.submitted_at)) / 3600) AS oldest
+			FROM achievement_references ar
+			JOIN students s ON ar.student_id = s.id
+			WHERE ar.status = 'submitted' AND ar.approval_step = 0
+			GROUP BY s.advisor_id
+		)
+		SELECT l.id, l.lecturer_id, u.full_name,
+		       COUNT(d.hours), COALESCE(AVG(d.hours), 0), COALESCE(MAX(d.hours), 0),
+		       COALESCE(p.pending, 0), COALESCE(p.overdue, 0), COALESCE(p.oldest, 0)
+		FROM lecturers l
+		JOIN users u ON l.user_id = u.id
+		LEFT JOIN decisions d ON d.approver_id = l.user_id
+		LEFT JOIN pending p ON p.advisor_id = l.id
+		WHERE ($2 = '' OR l.id::text = $2)
+		GROUP BY l.id, l.lecturer_id, u.full_name, p.pending, p.overdue, p.oldest
+		ORDER BY COALESCE(AVG(d.hours), 0) DESC, u.full_name ASC
+	`
+	rows, err := database.PostgresDB.Query(query, overdueBefore, lecturerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []*model.AdvisorReviewLatency{}
+	for rows.Next() {
+		item := &model.AdvisorReviewLatency{}
+		err := rows.Scan(
+			&item.LecturerID, &item.LecturerNumber, &item.FullName,
+			&item.ReviewedCount, &item.AvgReviewHours, &item.MaxReviewHours,
+			&item.PendingCount, &item.OverdueCount, &item.OldestPendingHrs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, item)
+	}
+	return report, rows.Err()
+}
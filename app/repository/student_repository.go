@@ -2,8 +2,11 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
 	"projek_uas/app/model" // Fixed import path to use app/model
 	"projek_uas/database"
+
+	"github.com/lib/pq"
 )
 
 type StudentRepository struct{}
@@ -56,6 +59,39 @@ func (r *StudentRepository) FindByID(id string) (*model.Student, error) {
 	return student, err
 }
 
+// FindByIDs loads several students with their user's name, keyed by id
+func (r *StudentRepository) FindByIDs(ids []string) (map[string]*model.Student, error) {
+	students := make(map[string]*model.Student, len(ids))
+	if len(ids) == 0 {
+		return students, nil
+	}
+
+	rows, err := database.PostgresDB.Query(`
+		SELECT s.id, s.user_id, s.student_id, s.program_study, s.academic_year, s.advisor_id, s.created_at, u.full_name
+		FROM students s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		student := &model.Student{User: &model.User{}}
+		err := rows.Scan(
+			&student.ID, &student.UserID, &student.StudentID, &student.ProgramStudy,
+			&student.AcademicYear, &student.AdvisorID, &student.CreatedAt, &student.User.FullName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		student.User.ID = student.UserID
+		students[student.ID] = student
+	}
+	return students, rows.Err()
+}
+
 func (r *StudentRepository) GetStudentsByAdvisorID(advisorID string) ([]string, error) {
 	query := "SELECT id FROM students WHERE advisor_id = $1"
 	rows, err := database.PostgresDB.Query(query, advisorID)
@@ -80,3 +116,50 @@ func (r *StudentRepository) UpdateAdvisor(studentID, advisorID string) error {
 	_, err := database.PostgresDB.Exec(query, advisorID, studentID)
 	return err
 }
+
+func (r *StudentRepository) FindByStudentNumber(studentNumber string) (*model.Student, error) {
+	student := &model.Student{}
+	query := `
+		SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at
+		FROM students WHERE student_id = $1
+	`
+	err := database.PostgresDB.QueryRow(query, studentNumber).Scan(
+		&student.ID, &student.UserID, &student.StudentID, &student.ProgramStudy,
+		&student.AcademicYear, &student.AdvisorID, &student.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return student, err
+}
+
+// FindIDs lists the students of a program study and cohort. Empty values are
+// not filtered on.
+func (r *StudentRepository) FindIDs(programStudy, cohort string) ([]string, error) {
+	query := "SELECT id FROM students WHERE 1=1"
+	var args []interface{}
+	if programStudy != "" {
+		args = append(args, programStudy)
+		query += fmt.Sprintf(" AND program_study = $%d", len(args))
+	}
+	if cohort != "" {
+		args = append(args, cohort)
+		query += fmt.Sprintf(" AND academic_year = $%d", len(args))
+	}
+
+	rows, err := database.PostgresDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	studentIDs := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		studentIDs = append(studentIDs, id)
+	}
+	return studentIDs, rows.Err()
+}
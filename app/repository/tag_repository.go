@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/database"
+	"projek_uas/helper"
+
+	"github.com/lib/pq"
+)
+
+type TagRepository struct{}
+
+func NewTagRepository() *TagRepository {
+	return &TagRepository{}
+}
+
+const tagSelect = `
+	SELECT t.id, t.name, t.parent_id, t.description, t.created_at, t.updated_at,
+	       COALESCE(ARRAY(SELECT s.synonym FROM tag_synonyms s WHERE s.tag_id = t.id ORDER BY s.synonym), '{}')
+	FROM tags t
+`
+
+func scanTag(scanner interface{ Scan(...interface{}) error }) (*model.Tag, error) {
+	tag := &model.Tag{}
+	err := scanner.Scan(
+		&tag.ID, &tag.Name, &tag.ParentID, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt,
+		pq.Array(&tag.Synonyms),
+	)
+	return tag, err
+}
+
+func (r *TagRepository) Create(tag *model.Tag) error {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO tags (name, normalized, parent_id, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, tag.Name, helper.TagKey(tag.Name), tag.ParentID, tag.Description).Scan(&tag.ID, &tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertSynonyms(tx, tag); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces the tag's fields and synonyms
+func (r *TagRepository) Update(tag *model.Tag) error {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tag.UpdatedAt = time.Now()
+	result, err := tx.Exec(`
+		UPDATE tags
+		SET name = $1, normalized = $2, parent_id = $3, description = $4, updated_at = $5
+		WHERE id = $6
+	`, tag.Name, helper.TagKey(tag.Name), tag.ParentID, tag.Description, tag.UpdatedAt, tag.ID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("tag not found")
+	}
+
+	if _, err := tx.Exec("DELETE FROM tag_synonyms WHERE tag_id = $1", tag.ID); err != nil {
+		return err
+	}
+	if err := insertSynonyms(tx, tag); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertSynonyms(tx *sql.Tx, tag *model.Tag) error {
+	for _, synonym := range tag.Synonyms {
+		_, err := tx.Exec(
+			"INSERT INTO tag_synonyms (tag_id, synonym, normalized) VALUES ($1, $2, $3)",
+			tag.ID, synonym, helper.TagKey(synonym),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the tag and its synonyms. Its children become top level
+// tags.
+func (r *TagRepository) Delete(id string) error {
+	result, err := database.PostgresDB.Exec("DELETE FROM tags WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("tag not found")
+	}
+
+	return nil
+}
+
+func (r *TagRepository) FindByID(id string) (*model.Tag, error) {
+	tag, err := scanTag(database.PostgresDB.QueryRow(tagSelect+" WHERE t.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return tag, err
+}
+
+// FindAll lists the whole vocabulary ordered by name
+func (r *TagRepository) FindAll() ([]*model.Tag, error) {
+	rows, err := database.PostgresDB.Query(tagSelect + " ORDER BY t.name ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*model.Tag{}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// FindByKeys maps each key (see helper.TagKey) that matches a tag name or
+// synonym to that tag. Keys without a match are left out.
+func (r *TagRepository) FindByKeys(keys []string) (map[string]*model.Tag, error) {
+	tags := make(map[string]*model.Tag)
+	if len(keys) == 0 {
+		return tags, nil
+	}
+
+	rows, err := database.PostgresDB.Query(`
+		SELECT t.normalized, t.id, t.name, t.parent_id FROM tags t WHERE t.normalized = ANY($1)
+		UNION ALL
+		SELECT s.normalized, t.id, t.name, t.parent_id
+		FROM tag_synonyms s
+		JOIN tags t ON s.tag_id = t.id
+		WHERE s.normalized = ANY($1)
+	`, pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		tag := &model.Tag{}
+		if err := rows.Scan(&key, &tag.ID, &tag.Name, &tag.ParentID); err != nil {
+			return nil, err
+		}
+		tags[key] = tag
+	}
+	return tags, rows.Err()
+}
+
+// Autocomplete returns up to limit tags whose name or a synonym contains the
+// key. Prefix matches come first, names before synonyms.
+func (r *TagRepository) Autocomplete(key string, limit int) ([]*model.TagSuggestion, error) {
+	rows, err := database.PostgresDB.Query(`
+		WITH RECURSIVE paths AS (
+			SELECT id, name::text AS path FROM tags WHERE parent_id IS NULL
+			UNION ALL
+			SELECT t.id, p.path || ' > ' || t.name FROM tags t JOIN paths p ON t.parent_id = p.id
+		), matches AS (
+			SELECT id AS tag_id, NULL::text AS synonym,
+			       CASE WHEN normalized LIKE $1 || '%' THEN 0 ELSE 2 END AS rank
+			FROM tags
+			WHERE normalized LIKE '%' || $1 || '%'
+			UNION ALL
+			SELECT tag_id, synonym,
+			       CASE WHEN normalized LIKE $1 || '%' THEN 1 ELSE 3 END
+			FROM tag_synonyms
+			WHERE normalized LIKE '%' || $1 || '%'
+		), best AS (
+			SELECT DISTINCT ON (tag_id) tag_id, synonym, rank
+			FROM matches
+			ORDER BY tag_id, rank, synonym
+		)
+		SELECT t.id, t.name, COALESCE(p.path, t.name), b.synonym
+		FROM best b
+		JOIN tags t ON b.tag_id = t.id
+		LEFT JOIN paths p ON p.id = t.id
+		ORDER BY b.rank, t.name
+		LIMIT $2
+	`, helper.EscapeLike(key), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []*model.TagSuggestion{}
+	for rows.Next() {
+		suggestion := &model.TagSuggestion{}
+		if err := rows.Scan(&suggestion.ID, &suggestion.Name, &suggestion.Path, &suggestion.MatchedSynonym); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
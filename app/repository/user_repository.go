@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -129,6 +130,60 @@ func (r *UserRepository) GetAll(limit, offset int) ([]*model.User, int64, error)
 	return users, total, err
 }
 
+// GetAllByCursor lists up to limit active users after the cursor, newest
+// first. more reports whether further rows exist in the direction of the
+// cursor.
+func (r *UserRepository) GetAllByCursor(cursor *model.Cursor, limit int) ([]*model.User, bool, error) {
+	condition, order, args := keysetCondition(cursor, "u.created_at", "u.id", 1)
+	query := `
+		SELECT u.id, u.username, u.email, u.full_name, u.role_id, u.is_active,
+		       u.created_at, u.updated_at, u.deleted_at, r.name as role_name
+		FROM users u
+		LEFT JOIN roles r ON u.role_id = r.id
+		WHERE u.deleted_at IS NULL
+	` + condition + order + fmt.Sprintf(" LIMIT $%d", len(args)+1)
+
+	rows, err := database.PostgresDB.Query(query, append(args, limit+1)...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	users := []*model.User{}
+	for rows.Next() {
+		user := &model.User{}
+		err := rows.Scan(
+			&user.ID, &user.Username, &user.Email, &user.FullName, &user.RoleID,
+			&user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.RoleName,
+		)
+		if err != nil {
+			return nil, false, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	more := len(users) > limit
+	if more {
+		users = users[:limit]
+	}
+	if cursor != nil && cursor.Backward {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+
+	return users, more, nil
+}
+
+func (r *UserRepository) CountActive() (int64, error) {
+	var total int64
+	err := database.PostgresDB.QueryRow("SELECT COUNT(*) FROM users WHERE deleted_at IS NULL").Scan(&total)
+	return total, err
+}
+
 func (r *UserRepository) Update(id string, req *model.UpdateUserRequest) error {
 	query := `
 		UPDATE users
@@ -219,6 +274,31 @@ func (r *UserRepository) GetRoleByName(roleName string) (*model.Role, error) {
 	return role, err
 }
 
+// GetUserIDsByRole returns the active users holding the given role
+func (r *UserRepository) GetUserIDsByRole(roleName string) ([]string, error) {
+	query := `
+		SELECT u.id
+		FROM users u
+		JOIN roles r ON u.role_id = r.id
+		WHERE r.name = $1 AND u.is_active = true AND u.deleted_at IS NULL
+	`
+	rows, err := database.PostgresDB.Query(query, roleName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, rows.Err()
+}
+
 func (r *UserRepository) HandleCreate(req *model.CreateUserRequest, studentRepo *StudentRepository, lecturerRepo *LecturerRepository) (*model.User, error) {
 	existing, err := r.FindByUsername(req.Username)
 	if err != nil {
@@ -301,6 +381,32 @@ func (r *UserRepository) HandleGetAll(page, limit int) ([]*model.User, *model.Pa
 	return users, pagination, nil
 }
 
+// HandleGetAllByCursor lists users with keyset pagination. The total is only
+// counted when withTotal is set.
+func (r *UserRepository) HandleGetAllByCursor(cursor *model.Cursor, limit int, withTotal bool) ([]*model.User, *model.CursorPagination, error) {
+	users, more, err := r.GetAllByCursor(cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var first, last *model.Cursor
+	if len(users) > 0 {
+		first = &model.Cursor{CreatedAt: users[0].CreatedAt, ID: users[0].ID}
+		last = &model.Cursor{CreatedAt: users[len(users)-1].CreatedAt, ID: users[len(users)-1].ID}
+	}
+	meta := helper.NewCursorPagination(limit, cursor, more, first, last)
+
+	if withTotal {
+		total, err := r.CountActive()
+		if err != nil {
+			return nil, nil, err
+		}
+		meta.TotalItems = &total
+	}
+
+	return users, &meta, nil
+}
+
 func (r *UserRepository) HandleUpdate(id string, req *model.UpdateUserRequest) error {
 	user, err := r.FindByID(id)
 	if err != nil {
@@ -362,6 +468,22 @@ func (r *UserRepository) HandleGetAllHTTP(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 
+	if helper.UseCursorPagination(c) {
+		cursor, err := helper.DecodeCursor(c.Query("cursor"))
+		if err != nil {
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		if limit < 1 || limit > 100 {
+			limit = 10
+		}
+
+		users, meta, err := r.HandleGetAllByCursor(cursor, limit, c.Query("include_total") == "true")
+		if err != nil {
+			return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return helper.CursorPaginatedResponse(c, users, *meta)
+	}
+
 	users, pagination, err := r.HandleGetAll(page, limit)
 	if err != nil {
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"projek_uas/app/model"
+	"projek_uas/helper"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// duplicateThreshold is the score from which an achievement is flagged
+	// as a likely duplicate of another one
+	duplicateThreshold = 0.6
+
+	// maxDuplicateCandidates bounds how many achievements are compared
+	maxDuplicateCandidates = 200
+)
+
+// scoreDuplicate rates how likely b reports the same achievement as a.
+// Matching certification numbers or attachments are conclusive, otherwise
+// the score weighs title similarity, a matching competition or certification
+// name, the event date and whether both belong to the same student.
+func scoreDuplicate(a, b *model.Achievement) (float64, []string) {
+	var reasons []string
+	score := 0.0
+
+	if a.Details.CertificationNumber != "" &&
+		helper.NormalizeText(a.Details.CertificationNumber) == helper.NormalizeText(b.Details.CertificationNumber) {
+		reasons = append(reasons, "same certification number")
+		score = 1
+	}
+
+	checksums := make(map[string]bool)
+	for _, attachment := range a.Attachments {
+		if attachment.Checksum != "" {
+			checksums[attachment.Checksum] = true
+		}
+	}
+	for _, attachment := range b.Attachments {
+		if checksums[attachment.Checksum] {
+			reasons = append(reasons, fmt.Sprintf("same attachment file (%s)", attachment.FileName))
+			score = 1
+			break
+		}
+	}
+
+	weighted := 0.0
+
+	titleSimilarity := helper.TextSimilarity(a.Title, b.Title)
+	if titleSimilarity >= 0.5 {
+		reasons = append(reasons, fmt.Sprintf("similar title (%.0f%%)", titleSimilarity*100))
+	}
+	weighted += 0.5 * titleSimilarity
+
+	if sameName(a.Details.CompetitionName, b.Details.CompetitionName) {
+		reasons = append(reasons, "same competition name")
+		weighted += 0.3
+	} else if sameName(a.Details.CertificationName, b.Details.CertificationName) {
+		reasons = append(reasons, "same certification name")
+		weighted += 0.3
+	}
+
+	if a.Details.EventDate != nil && b.Details.EventDate != nil &&
+		a.Details.EventDate.Format("2006-01-02") == b.Details.EventDate.Format("2006-01-02") {
+		reasons = append(reasons, "same event date")
+		weighted += 0.2
+	}
+
+	if a.StudentID == b.StudentID {
+		reasons = append(reasons, "same student")
+		weighted += 0.2
+	}
+
+	if weighted > score {
+		score = weighted
+	}
+	if score > 1 {
+		score = 1
+	}
+
+	return score, reasons
+}
+
+func sameName(a, b string) bool {
+	return a != "" && helper.NormalizeText(a) == helper.NormalizeText(b)
+}
+
+// detectDuplicates compares the achievement with existing ones and stores
+// the likely duplicates for the reviewers
+func (s *AchievementService) detectDuplicates(ref *model.AchievementReference, achievement *model.Achievement) error {
+	others, err := s.achievementRepo.FindMongoDuplicateCandidates(achievement, maxDuplicateCandidates)
+	if err != nil {
+		return err
+	}
+
+	mongoIDs := make([]string, 0, len(others))
+	for _, other := range others {
+		mongoIDs = append(mongoIDs, other.ID.Hex())
+	}
+	refs, err := s.achievementRepo.FindReferencesByMongoIDs(mongoIDs)
+	if err != nil {
+		return err
+	}
+
+	candidates := []*model.DuplicateCandidate{}
+	for _, other := range others {
+		otherRef, ok := refs[other.ID.Hex()]
+		if !ok || otherRef.ID == ref.ID {
+			continue
+		}
+
+		score, reasons := scoreDuplicate(achievement, other)
+		if score < duplicateThreshold {
+			continue
+		}
+
+		candidates = append(candidates, &model.DuplicateCandidate{
+			AchievementID: otherRef.ID,
+			StudentID:     otherRef.StudentID,
+			Title:         other.Title,
+			Status:        otherRef.Status,
+			Score:         score,
+			Reasons:       reasons,
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+
+	return s.duplicateRepo.ReplaceFlags(ref.ID, candidates)
+}
+
+// loadDuplicateCandidates attaches the stored duplicate flags to the reference
+func (s *AchievementService) loadDuplicateCandidates(ref *model.AchievementReference) error {
+	candidates, err := s.duplicateRepo.FindFlags(ref.ID)
+	if err != nil {
+		return err
+	}
+
+	mongoIDs := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		mongoIDs = append(mongoIDs, candidate.MongoID)
+	}
+	achievements, err := s.achievementRepo.FindMongoByIDs(mongoIDs, true)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range candidates {
+		if achievement, ok := achievements[candidate.MongoID]; ok {
+			candidate.Title = achievement.Title
+		}
+	}
+
+	ref.DuplicateCandidates = candidates
+	return nil
+}
+
+// SetDuplicateOf links the achievement to the one it duplicates, or removes
+// the link when duplicateOf is nil. Advisors may only mark their advisees'
+// achievements.
+func (s *AchievementService) SetDuplicateOf(id, userID, roleName string, duplicateOf *string) error {
+	ref, err := s.achievementRepo.FindReferenceByID(id)
+	if err != nil {
+		return err
+	}
+	if ref == nil {
+		return errors.New("achievement not found")
+	}
+
+	if roleName == "Dosen Wali" {
+		if err := s.requireAdvisor(ref, userID); err != nil {
+			return err
+		}
+	}
+
+	if duplicateOf != nil {
+		if *duplicateOf == ref.ID {
+			return errors.New("an achievement cannot duplicate itself")
+		}
+
+		original, err := s.achievementRepo.FindReferenceByID(*duplicateOf)
+		if err != nil {
+			return err
+		}
+		if original == nil {
+			return errors.New("original achievement not found")
+		}
+
+		// Follow the original's own links so duplicates never form a cycle,
+		// which would drop every achievement in it from the reports
+		visited := map[string]bool{original.ID: true}
+		for next := original.DuplicateOf; next != nil; {
+			if *next == ref.ID {
+				return errors.New("the original achievement is already marked as a duplicate of this one")
+			}
+			if visited[*next] {
+				break
+			}
+			visited[*next] = true
+
+			older, err := s.achievementRepo.FindReferenceByID(*next)
+			if err != nil {
+				return err
+			}
+			if older == nil {
+				break
+			}
+			next = older.DuplicateOf
+		}
+	}
+
+	if err := s.achievementRepo.SetDuplicateOf(ref.ID, duplicateOf); err != nil {
+		return err
+	}
+
+	// Rankings leave out duplicates of verified achievements
+	if ref.Status == model.StatusVerified {
+		s.leaderboards.invalidate()
+	}
+	return nil
+}
+
+func (s *AchievementService) HandleSetDuplicateOfHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	var req model.SetDuplicateOfRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := s.SetDuplicateOf(id, userID, roleName, req.DuplicateOf); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Duplicate link updated", nil)
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"projek_uas/app/model"
+	"projek_uas/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// exportBatchSize is how many achievements a listing export reads per query
+const exportBatchSize = 500
+
+var statisticsExportColumns = []model.ExportColumn{
+	{Header: "Rincian", Type: model.ExportColumnText},
+	{Header: "Kunci", Type: model.ExportColumnText},
+	{Header: "Label", Type: model.ExportColumnText},
+	{Header: "Jumlah", Type: model.ExportColumnNumber},
+	{Header: "Total Poin", Type: model.ExportColumnNumber},
+}
+
+func statisticsExportTable(stats *model.AchievementStatistics, period *model.AcademicPeriod) helper.ExportTable {
+	breakdowns := []struct {
+		name    string
+		buckets []model.StatisticsBucket
+	}{
+		{"Jenis", stats.ByType},
+		{"Bulan", stats.ByMonth},
+		{"Semester", stats.BySemester},
+		{"Tingkat Kompetisi", stats.ByCompetitionLevel},
+		{"Program Studi", stats.ByProgramStudy},
+		{"Angkatan", stats.ByCohort},
+		{"Dosen Wali", stats.ByAdvisor},
+		{"Periode Akademik", stats.ByPeriod},
+	}
+
+	subtitle := fmt.Sprintf("Total %d prestasi, %d poin", stats.TotalVerified, stats.TotalPoints)
+	if period != nil {
+		subtitle += ", Periode " + period.Name
+	}
+
+	return helper.ExportTable{
+		Title:    "Statistik Prestasi Terverifikasi",
+		Subtitle: subtitle,
+		Columns:  statisticsExportColumns,
+		Rows: func(emit helper.ExportRowFunc) error {
+			for _, breakdown := range breakdowns {
+				for _, bucket := range breakdown.buckets {
+					err := emit([]interface{}{breakdown.name, bucket.Key, bucket.Label, bucket.Count, bucket.TotalPoints})
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	}
+}
+
+var leaderboardExportColumns = []model.ExportColumn{
+	{Header: "Peringkat", Type: model.ExportColumnNumber},
+	{Header: "NIM", Type: model.ExportColumnText},
+	{Header: "Nama", Type: model.ExportColumnText},
+	{Header: "Program Studi", Type: model.ExportColumnText},
+	{Header: "Angkatan", Type: model.ExportColumnText},
+	{Header: "Total Poin", Type: model.ExportColumnNumber},
+	{Header: "Jumlah Prestasi", Type: model.ExportColumnNumber},
+	{Header: "Verifikasi Terakhir", Type: model.ExportColumnDate},
+}
+
+func leaderboardExportTable(board *model.Leaderboard) helper.ExportTable {
+	subtitle := "Berdasarkan poin"
+	if board.Filter.By == model.LeaderboardByCount {
+		subtitle = "Berdasarkan jumlah prestasi"
+	}
+	for _, part := range []struct{ label, value string }{
+		{"Program studi", board.Filter.ProgramStudy},
+		{"Angkatan", board.Filter.Cohort},
+		{"Periode", board.Filter.Period},
+		{"Jenis", board.Filter.AchievementType},
+	} {
+		if part.value != "" {
+			subtitle += fmt.Sprintf(", %s %s", part.label, part.value)
+		}
+	}
+
+	return helper.ExportTable{
+		Title:    "Peringkat Mahasiswa Berprestasi",
+		Subtitle: subtitle,
+		Columns:  leaderboardExportColumns,
+		Rows: func(emit helper.ExportRowFunc) error {
+			for _, entry := range board.Entries {
+				err := emit([]interface{}{
+					entry.Rank, entry.StudentNumber, entry.FullName, entry.ProgramStudy, entry.AcademicYear,
+					entry.TotalPoints, entry.AchievementCount, entry.LastVerifiedAt,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+var achievementExportColumns = []model.ExportColumn{
+	{Header: "ID", Type: model.ExportColumnText},
+	{Header: "NIM", Type: model.ExportColumnText},
+	{Header: "Nama", Type: model.ExportColumnText},
+	{Header: "Program Studi", Type: model.ExportColumnText},
+	{Header: "Judul", Type: model.ExportColumnText},
+	{Header: "Jenis", Type: model.ExportColumnText},
+	{Header: "Tingkat", Type: model.ExportColumnText},
+	{Header: "Tanggal Kegiatan", Type: model.ExportColumnDate},
+	{Header: "Poin", Type: model.ExportColumnNumber},
+	{Header: "Status", Type: model.ExportColumnText},
+	{Header: "Diajukan", Type: model.ExportColumnDate},
+	{Header: "Diverifikasi", Type: model.ExportColumnDate},
+}
+
+// achievementExportTable lists every achievement the user may see, reading
+// them in batches while the export is written. A period, when given, keeps
+// only the achievements assigned to it.
+func (s *AchievementService) achievementExportTable(userID, roleName, status string, period *model.AcademicPeriod) (helper.ExportTable, error) {
+	table := helper.ExportTable{Title: "Daftar Prestasi Mahasiswa", Columns: achievementExportColumns}
+	var subtitle []string
+	if status != "" {
+		subtitle = append(subtitle, "Status "+status)
+	}
+	if period != nil {
+		subtitle = append(subtitle, "Periode "+period.Name)
+	}
+	table.Subtitle = strings.Join(subtitle, ", ")
+
+	studentIDs, scoped, err := s.scopeStudentIDs(userID, roleName)
+	if err != nil {
+		return table, err
+	}
+
+	table.Rows = func(emit helper.ExportRowFunc) error {
+		if scoped && len(studentIDs) == 0 {
+			return nil
+		}
+
+		var cursor *model.Cursor
+		for {
+			refs, more, err := s.achievementRepo.ListReferencesByCursor(studentIDs, status, cursor, exportBatchSize)
+			if err != nil {
+				return err
+			}
+			if err := s.achievementRepo.AttachAchievements(refs, true); err != nil {
+				return err
+			}
+
+			ids := make([]string, 0, len(refs))
+			for _, ref := range refs {
+				ids = append(ids, ref.StudentID)
+			}
+			students, err := s.studentRepo.FindByIDs(ids)
+			if err != nil {
+				return err
+			}
+
+			for _, ref := range refs {
+				// Revoked achievements are only exported when asked for by status
+				if status == "" && ref.Status == model.StatusRevoked {
+					continue
+				}
+				if period != nil && (ref.PeriodID == nil || *ref.PeriodID != period.ID) {
+					continue
+				}
+				if err := emit(achievementExportRow(ref, students[ref.StudentID])); err != nil {
+					return err
+				}
+			}
+
+			if !more || len(refs) == 0 {
+				return nil
+			}
+			last := refs[len(refs)-1]
+			cursor = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+	}
+
+	return table, nil
+}
+
+func achievementExportRow(ref *model.AchievementReference, student *model.Student) []interface{} {
+	row := make([]interface{}, len(achievementExportColumns))
+	row[0] = ref.ID
+	if student != nil {
+		row[1] = student.StudentID
+		row[2] = student.User.FullName
+		row[3] = student.ProgramStudy
+	}
+	if achievement := ref.Achievement; achievement != nil {
+		row[4] = achievement.Title
+		row[5] = achievement.AchievementType
+		row[6] = achievement.Details.CompetitionLevel
+		row[7] = achievement.Details.EventDate
+		row[8] = achievement.Points
+	}
+	row[9] = ref.Status
+	row[10] = ref.SubmittedAt
+	row[11] = ref.VerifiedAt
+	return row
+}
+
+// sendExport writes an export headed with the institution name
+func (s *AchievementService) sendExport(c *fiber.Ctx, format, filename string, table helper.ExportTable) error {
+	return helper.SendExport(c, format, filename, s.institutionName, table)
+}
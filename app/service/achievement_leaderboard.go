@@ -0,0 +1,320 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// leaderboardTTL bounds how long a ranking is served from the cache. Rankings
+// are dropped as soon as a verification changes them; the TTL only covers
+// changes made elsewhere, such as a student moving to another program study.
+const leaderboardTTL = 15 * time.Minute
+
+// leaderboardCacheSize bounds how many filters keep a cached ranking. When
+// it is reached the oldest ranking makes room for the new one.
+const leaderboardCacheSize = 100
+
+// Filter values are bounded by the columns they are matched against
+const (
+	maxProgramStudyLength = 100
+	maxCohortLength       = 10
+)
+
+var achievementTypePattern = regexp.MustCompile(`^[a-z][a-z_-]{0,49}$`)
+
+// leaderboardCache keeps complete, unscoped rankings per filter. generation
+// counts the invalidations, a ranking computed before the latest one is not
+// stored.
+type leaderboardCache struct {
+	mu         sync.Mutex
+	entries    map[model.LeaderboardFilter]*model.Leaderboard
+	generation uint64
+}
+
+func newLeaderboardCache() *leaderboardCache {
+	return &leaderboardCache{entries: make(map[model.LeaderboardFilter]*model.Leaderboard)}
+}
+
+func (c *leaderboardCache) get(filter model.LeaderboardFilter) *model.Leaderboard {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	board, ok := c.entries[filter]
+	if !ok || time.Since(board.GeneratedAt) > leaderboardTTL {
+		return nil
+	}
+	return board
+}
+
+// currentGeneration is taken before a ranking is computed and handed to put
+func (c *leaderboardCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// put stores the ranking unless the cache was invalidated after generation
+// was taken, in which case the ranking may already be stale
+func (c *leaderboardCache) put(filter model.LeaderboardFilter, board *model.Leaderboard, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	if _, ok := c.entries[filter]; !ok && len(c.entries) >= leaderboardCacheSize {
+		c.evict()
+	}
+	c.entries[filter] = board
+}
+
+// evict drops the expired rankings, or the oldest one when none has expired
+func (c *leaderboardCache) evict() {
+	var oldest *model.LeaderboardFilter
+	var oldestAt time.Time
+	for filter, board := range c.entries {
+		if time.Since(board.GeneratedAt) > leaderboardTTL {
+			delete(c.entries, filter)
+			continue
+		}
+		if oldest == nil || board.GeneratedAt.Before(oldestAt) {
+			f := filter
+			oldest, oldestAt = &f, board.GeneratedAt
+		}
+	}
+	if len(c.entries) >= leaderboardCacheSize && oldest != nil {
+		delete(c.entries, *oldest)
+	}
+}
+
+func (c *leaderboardCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[model.LeaderboardFilter]*model.Leaderboard)
+	c.generation++
+}
+
+// GetLeaderboard ranks students by their verified achievements. Admins and
+// Kaprodi see the whole ranking, advisors only their advisees and students
+// only themselves; ranks are always those of the whole ranking. A limit of 0
+// returns every visible entry.
+func (s *AchievementService) GetLeaderboard(userID, roleName string, filter model.LeaderboardFilter, limit int) (*model.Leaderboard, error) {
+	board, err := s.rankStudents(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	studentIDs, scoped, err := s.scopeStudentIDs(userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.Leaderboard{
+		Filter:      board.Filter,
+		TotalRanked: board.TotalRanked,
+		GeneratedAt: board.GeneratedAt,
+		Entries:     []model.LeaderboardEntry{},
+	}
+
+	visible := make(map[string]bool, len(studentIDs))
+	for _, id := range studentIDs {
+		visible[id] = true
+	}
+	for _, entry := range board.Entries {
+		if limit > 0 && len(result.Entries) == limit {
+			break
+		}
+		if scoped && !visible[entry.StudentID] {
+			continue
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+
+	return result, nil
+}
+
+// rankStudents returns the complete ranking for the filter, from the cache
+// when it is still current
+func (s *AchievementService) rankStudents(filter model.LeaderboardFilter) (*model.Leaderboard, error) {
+	if board := s.leaderboards.get(filter); board != nil {
+		return board, nil
+	}
+	generation := s.leaderboards.currentGeneration()
+
+	rows, err := s.achievementRepo.FindCreditedForLeaderboard(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	mongoIDs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		mongoIDs = append(mongoIDs, row.MongoAchievementID)
+	}
+	documents, err := s.achievementRepo.FindMongoByIDs(mongoIDs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	byStudent := make(map[string]*model.LeaderboardEntry)
+	for _, row := range rows {
+		achievement, ok := documents[row.MongoAchievementID]
+		if !ok {
+			continue
+		}
+		if filter.AchievementType != "" && achievement.AchievementType != filter.AchievementType {
+			continue
+		}
+
+		entry, ok := byStudent[row.StudentID]
+		if !ok {
+			entry = &model.LeaderboardEntry{
+				StudentID:     row.StudentID,
+				StudentNumber: row.StudentNumber,
+				FullName:      row.FullName,
+				ProgramStudy:  row.ProgramStudy,
+				AcademicYear:  row.AcademicYear,
+				AdvisorID:     row.AdvisorID,
+			}
+			byStudent[row.StudentID] = entry
+		}
+		entry.TotalPoints += model.MemberPoints(achievement.Points, row.TeamPointRule, row.TeamSize)
+		entry.AchievementCount++
+		if row.VerifiedAt != nil && (entry.LastVerifiedAt == nil || row.VerifiedAt.After(*entry.LastVerifiedAt)) {
+			entry.LastVerifiedAt = row.VerifiedAt
+		}
+	}
+
+	entries := make([]model.LeaderboardEntry, 0, len(byStudent))
+	for _, entry := range byStudent {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return rankedBefore(&entries[i], &entries[j], filter.By)
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+
+	board := &model.Leaderboard{
+		Filter:      filter,
+		TotalRanked: len(entries),
+		GeneratedAt: time.Now(),
+		Entries:     entries,
+	}
+	s.leaderboards.put(filter, board, generation)
+	return board, nil
+}
+
+// rankedBefore orders two students. Ties on the chosen criterion are broken
+// by the other criterion, then by who reached their score first (earliest
+// last verification), then by NIM so the order is always stable.
+func rankedBefore(a, b *model.LeaderboardEntry, by string) bool {
+	if by == model.LeaderboardByCount {
+		if a.AchievementCount != b.AchievementCount {
+			return a.AchievementCount > b.AchievementCount
+		}
+		if a.TotalPoints != b.TotalPoints {
+			return a.TotalPoints > b.TotalPoints
+		}
+	} else {
+		if a.TotalPoints != b.TotalPoints {
+			return a.TotalPoints > b.TotalPoints
+		}
+		if a.AchievementCount != b.AchievementCount {
+			return a.AchievementCount > b.AchievementCount
+		}
+	}
+
+	switch {
+	case a.LastVerifiedAt == nil && b.LastVerifiedAt != nil:
+		return false
+	case a.LastVerifiedAt != nil && b.LastVerifiedAt == nil:
+		return true
+	case a.LastVerifiedAt != nil && !a.LastVerifiedAt.Equal(*b.LastVerifiedAt):
+		return a.LastVerifiedAt.Before(*b.LastVerifiedAt)
+	}
+
+	return a.StudentNumber < b.StudentNumber
+}
+
+// parseLeaderboardFilter reads the filter from the query. Values are trimmed
+// and the type lowercased so equivalent queries share a cached ranking. The
+// period is resolved to an academic period, "active" standing for the active
+// one.
+func (s *AchievementService) parseLeaderboardFilter(c *fiber.Ctx) (model.LeaderboardFilter, error) {
+	filter := model.LeaderboardFilter{
+		By:              c.Query("by", model.LeaderboardByPoints),
+		ProgramStudy:    strings.TrimSpace(c.Query("program_study")),
+		Cohort:          strings.TrimSpace(c.Query("cohort")),
+		Period:          strings.TrimSpace(c.Query("period")),
+		AchievementType: strings.ToLower(strings.TrimSpace(c.Query("type"))),
+		IncludeExpired:  c.Query("include_expired") == "true",
+	}
+
+	if filter.By != model.LeaderboardByPoints && filter.By != model.LeaderboardByCount {
+		return filter, errors.New("by must be points or count")
+	}
+	if len(filter.ProgramStudy) > maxProgramStudyLength {
+		return filter, errors.New("program_study is too long")
+	}
+	if len(filter.Cohort) > maxCohortLength {
+		return filter, errors.New("cohort is too long")
+	}
+	if filter.AchievementType != "" && !achievementTypePattern.MatchString(filter.AchievementType) {
+		return filter, errors.New("invalid achievement type")
+	}
+	period, err := s.resolvePeriod(filter.Period)
+	if err != nil {
+		return filter, err
+	}
+	if period != nil {
+		filter.Period, filter.PeriodID = period.Code, period.ID
+	}
+
+	return filter, nil
+}
+
+func (s *AchievementService) HandleLeaderboardHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	filter, err := s.parseLeaderboardFilter(c)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	format, err := helper.ParseExportFormat(c)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	// Exports hold the whole ranking unless a limit is asked for
+	if format != "" && c.Query("limit") == "" {
+		limit = 0
+	}
+
+	board, err := s.GetLeaderboard(userID, roleName, filter, limit)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if format != "" {
+		return s.sendExport(c, format, "peringkat-mahasiswa", leaderboardExportTable(board))
+	}
+
+	return helper.SuccessResponse(c, "Leaderboard retrieved", board)
+}
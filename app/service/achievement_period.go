@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"projek_uas/app/model"
+)
+
+// activePeriod is the period filter value standing for the active period
+const activePeriod = "active"
+
+// resolvePeriod looks up the academic period a report is filtered on by its
+// code, or the active period for "active". An empty code means no filter and
+// returns nil.
+func (s *AchievementService) resolvePeriod(code string) (*model.AcademicPeriod, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	var period *model.AcademicPeriod
+	var err error
+	if code == activePeriod {
+		period, err = s.periodRepo.FindActive()
+	} else {
+		period, err = s.periodRepo.FindByCode(code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if period == nil {
+		if code == activePeriod {
+			return nil, errors.New("no academic period is active")
+		}
+		return nil, errors.New("academic period not found")
+	}
+	return period, nil
+}
+
+// periodIDForDate returns the id of the academic period the date falls in,
+// or nil when no period covers it
+func (s *AchievementService) periodIDForDate(date time.Time) (*string, error) {
+	period, err := s.periodRepo.FindForDate(date)
+	if err != nil || period == nil {
+		return nil, err
+	}
+	return &period.ID, nil
+}
+
+func equalStringPointers(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
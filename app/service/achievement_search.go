@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"projek_uas/app/model"
+	"projek_uas/helper"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SearchAchievements combines a text query with structured filters. The
+// candidate set is scoped in PostgreSQL the same way as GetAchievements and
+// then searched in MongoDB.
+func (s *AchievementService) SearchAchievements(userID, roleName string, filter *model.AchievementSearchFilter, page, limit int) ([]*model.AchievementReference, *model.Pagination, error) {
+	offset := (page - 1) * limit
+	empty := &model.Pagination{Page: page, Limit: limit}
+
+	studentIDs, scoped, err := s.scopeStudentIDs(userID, roleName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if scoped && len(studentIDs) == 0 {
+		return []*model.AchievementReference{}, empty, nil
+	}
+
+	// The search always runs over documents with a live reference, also for
+	// admins, so documents still waiting in the outbox, compensated or
+	// orphaned never count towards the total
+	restrictTo, err := s.achievementRepo.FindMongoIDsForSearch(studentIDs, filter.Status, filter.ProgramStudy)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(restrictTo) == 0 {
+		return []*model.AchievementReference{}, empty, nil
+	}
+
+	achievements, total, err := s.achievementRepo.SearchMongo(filter, restrictTo, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mongoIDs := make([]string, 0, len(achievements))
+	for _, achievement := range achievements {
+		mongoIDs = append(mongoIDs, achievement.ID.Hex())
+	}
+	refsByMongoID, err := s.achievementRepo.FindReferencesByMongoIDs(mongoIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Keep the relevance order of the search
+	refs := []*model.AchievementReference{}
+	for _, achievement := range achievements {
+		ref, ok := refsByMongoID[achievement.ID.Hex()]
+		if !ok {
+			continue
+		}
+		ref.Achievement = achievement
+		refs = append(refs, ref)
+	}
+
+	totalPages := int(total) / limit
+	if int(total)%limit != 0 {
+		totalPages++
+	}
+
+	pagination := &model.Pagination{
+		Page:       page,
+		Limit:      limit,
+		TotalItems: total,
+		TotalPages: totalPages,
+	}
+
+	return refs, pagination, nil
+}
+
+// parseSearchFilter reads the search filters from the query string. Dates
+// use YYYY-MM-DD and event_to includes the whole day.
+func parseSearchFilter(c *fiber.Ctx) (*model.AchievementSearchFilter, error) {
+	filter := &model.AchievementSearchFilter{
+		Query:        strings.TrimSpace(c.Query("q")),
+		Type:         c.Query("type"),
+		Level:        c.Query("level"),
+		Medal:        c.Query("medal"),
+		Status:       c.Query("status"),
+		ProgramStudy: c.Query("program_study"),
+	}
+
+	if tags := c.Query("tags"); tags != "" {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+
+	if from := c.Query("event_from"); from != "" {
+		date, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, errors.New("event_from must be a date in YYYY-MM-DD format")
+		}
+		filter.EventFrom = &date
+	}
+	if to := c.Query("event_to"); to != "" {
+		date, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, errors.New("event_to must be a date in YYYY-MM-DD format")
+		}
+		date = date.Add(24*time.Hour - time.Nanosecond)
+		filter.EventTo = &date
+	}
+
+	if min := c.Query("min_points"); min != "" {
+		points, err := strconv.Atoi(min)
+		if err != nil {
+			return nil, errors.New("min_points must be a number")
+		}
+		filter.MinPoints = &points
+	}
+	if max := c.Query("max_points"); max != "" {
+		points, err := strconv.Atoi(max)
+		if err != nil {
+			return nil, errors.New("max_points must be a number")
+		}
+		filter.MaxPoints = &points
+	}
+
+	return filter, nil
+}
+
+func (s *AchievementService) HandleSearchHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	filter, err := parseSearchFilter(c)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	// Tags are stored under their canonical names
+	if filter.Tags, err = s.tagService.NormalizeTags(filter.Tags); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	achievements, pagination, err := s.SearchAchievements(userID, roleName, filter, page, limit)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.PaginatedResponse(c, achievements, *pagination)
+}
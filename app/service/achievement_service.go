@@ -1,20 +1,68 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"projek_uas/app/model"
 	"projek_uas/app/repository"
+	"projek_uas/helper"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AchievementService struct {
-	achievementRepo *repository.AchievementRepository
-	studentRepo     *repository.StudentRepository
+	achievementRepo     *repository.AchievementRepository
+	studentRepo         *repository.StudentRepository
+	lecturerRepo        *repository.LecturerRepository
+	revisionRepo        *repository.RevisionRepository
+	commentRepo         *repository.CommentRepository
+	approvalRepo        *repository.ApprovalRepository
+	memberRepo          *repository.MemberRepository
+	duplicateRepo       *repository.DuplicateRepository
+	outboxService       *OutboxService
+	notificationService *NotificationService
+	tagService          *TagService
+	periodRepo          *repository.PeriodRepository
+	leaderboards        *leaderboardCache
+	institutionName     string
 }
 
-func NewAchievementService(achievementRepo *repository.AchievementRepository, studentRepo *repository.StudentRepository) *AchievementService {
+func NewAchievementService(
+	achievementRepo *repository.AchievementRepository,
+	studentRepo *repository.StudentRepository,
+	lecturerRepo *repository.LecturerRepository,
+	revisionRepo *repository.RevisionRepository,
+	commentRepo *repository.CommentRepository,
+	approvalRepo *repository.ApprovalRepository,
+	memberRepo *repository.MemberRepository,
+	duplicateRepo *repository.DuplicateRepository,
+	outboxService *OutboxService,
+	notificationService *NotificationService,
+	tagService *TagService,
+	periodRepo *repository.PeriodRepository,
+	institutionName string,
+) *AchievementService {
 	return &AchievementService{
-		achievementRepo: achievementRepo,
-		studentRepo:     studentRepo,
+		achievementRepo:     achievementRepo,
+		studentRepo:         studentRepo,
+		lecturerRepo:        lecturerRepo,
+		revisionRepo:        revisionRepo,
+		commentRepo:         commentRepo,
+		approvalRepo:        approvalRepo,
+		memberRepo:          memberRepo,
+		duplicateRepo:       duplicateRepo,
+		outboxService:       outboxService,
+		notificationService: notificationService,
+		tagService:          tagService,
+		periodRepo:          periodRepo,
+		leaderboards:        newLeaderboardCache(),
+		institutionName:     institutionName,
 	}
 }
 
@@ -28,76 +76,167 @@ func (s *AchievementService) CreateAchievement(userID string, req *model.CreateA
 		return nil, errors.New("student profile not found")
 	}
 
-	// Create achievement in MongoDB
+	team, err := s.resolveTeam(student, req.Members)
+	if err != nil {
+		return nil, err
+	}
+	teamPointRule, err := validateTeamPointRule(req.TeamPointRule)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.tagService.NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := []model.Attachment{}
+	for _, attachment := range req.Attachments {
+		if attachment.UploadedAt.IsZero() {
+			attachment.UploadedAt = time.Now()
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	// The document id is assigned up front so the reference and the outbox
+	// event creating the document can be committed together
+	now := time.Now()
 	achievement := &model.Achievement{
+		ID:              primitive.NewObjectID(),
 		StudentID:       student.ID,
 		AchievementType: req.AchievementType,
 		Title:           req.Title,
 		Description:     req.Description,
 		Details:         req.Details,
-		Tags:            req.Tags,
+		Tags:            tags,
 		Points:          req.Points,
-		Attachments:     []model.Attachment{},
+		Attachments:     attachments,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	payload, err := json.Marshal(achievement)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.achievementRepo.CreateMongo(achievement); err != nil {
+	periodDate := now
+	if req.Details.EventDate != nil {
+		periodDate = *req.Details.EventDate
+	}
+	periodID, err := s.periodIDForDate(periodDate)
+	if err != nil {
 		return nil, err
 	}
 
-	// Create reference in PostgreSQL
 	ref := &model.AchievementReference{
 		StudentID:          student.ID,
 		MongoAchievementID: achievement.ID.Hex(),
-		Status:             "draft",
+		Status:             model.StatusDraft,
+		TeamPointRule:      teamPointRule,
+		PeriodID:           periodID,
+	}
+	event := &model.OutboxEvent{
+		MongoAchievementID: ref.MongoAchievementID,
+		Operation:          model.OutboxCreateDocument,
+		Payload:            payload,
 	}
 
-	if err := s.achievementRepo.CreateReference(ref); err != nil {
+	if err := s.achievementRepo.CreateReferenceWithOutbox(ref, team, event); err != nil {
 		return nil, err
 	}
 
 	ref.Achievement = achievement
+
+	// The achievement exists once the transaction commits, the steps below
+	// only log their failures so the client does not create it again. The
+	// outbox relay retries the document later; a missing initial revision is
+	// recorded as the baseline on the first update, and duplicates are
+	// checked again on submit.
+	if err := s.outboxService.Dispatch(event); err != nil {
+		log.Printf("Achievement %s: document creation deferred: %v", ref.ID, err)
+	} else {
+		if err := s.recordRevision(achievement, userID, ref.Status, ""); err != nil {
+			log.Printf("Achievement %s: initial revision not recorded: %v", ref.ID, err)
+		}
+		if err := s.detectDuplicates(ref, achievement); err != nil {
+			log.Printf("Achievement %s: duplicate check failed: %v", ref.ID, err)
+		}
+	}
+
+	if err := s.loadMembers(ref); err != nil {
+		log.Printf("Achievement %s: loading team failed: %v", ref.ID, err)
+	}
 	return ref, nil
 }
 
-func (s *AchievementService) GetAchievements(userID, roleName string, status string, page, limit int) ([]*model.AchievementReference, *model.Pagination, error) {
-	offset := (page - 1) * limit
-	var studentIDs []string
-	var err error
-
+// scopeStudentIDs returns the students whose achievements the user may list:
+// students their own, advisors their advisees'. scoped is false for roles
+// that see everything.
+func (s *AchievementService) scopeStudentIDs(userID, roleName string) (studentIDs []string, scoped bool, err error) {
 	if roleName == "Mahasiswa" {
 		student, err := s.studentRepo.FindByUserID(userID)
 		if err != nil {
-			return nil, nil, err
+			return nil, true, err
 		}
 		if student != nil {
 			studentIDs = []string{student.ID}
 		}
+		return studentIDs, true, nil
 	} else if roleName == "Dosen Wali" {
-		lecturer, err := repository.NewLecturerRepository().FindByUserID(userID)
+		lecturer, err := s.lecturerRepo.FindByUserID(userID)
 		if err != nil {
-			return nil, nil, err
+			return nil, true, err
 		}
 		if lecturer != nil {
 			studentIDs, err = s.studentRepo.GetStudentsByAdvisorID(lecturer.ID)
 			if err != nil {
-				return nil, nil, err
+				return nil, true, err
 			}
 		}
+		return studentIDs, true, nil
 	}
-	// Admin gets all (empty studentIDs)
 
-	refs, total, err := s.achievementRepo.GetReferences(studentIDs, status, limit, offset)
+	// Admin gets all
+	return nil, false, nil
+}
+
+// authorizeStudent checks that the user may see the student's records:
+// students only their own, advisors only their advisees'
+func (s *AchievementService) authorizeStudent(studentID, userID, roleName string) error {
+	studentIDs, scoped, err := s.scopeStudentIDs(userID, roleName)
 	if err != nil {
-		return nil, nil, err
+		return err
+	}
+	if !scoped {
+		return nil
 	}
 
-	// Fetch achievement details from MongoDB
-	for _, ref := range refs {
-		achievement, err := s.achievementRepo.FindMongoByID(ref.MongoAchievementID)
-		if err == nil {
-			ref.Achievement = achievement
+	for _, id := range studentIDs {
+		if id == studentID {
+			return nil
 		}
 	}
+	return errors.New("access denied")
+}
+
+// GetAchievements lists a page of achievements visible to the user. With
+// summary set the documents leave out heavy fields such as the description
+// and attachments.
+func (s *AchievementService) GetAchievements(userID, roleName string, status string, page, limit int, summary bool) ([]*model.AchievementReference, *model.Pagination, error) {
+	offset := (page - 1) * limit
+
+	studentIDs, scoped, err := s.scopeStudentIDs(userID, roleName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if scoped && len(studentIDs) == 0 {
+		return []*model.AchievementReference{}, &model.Pagination{Page: page, Limit: limit}, nil
+	}
+
+	refs, total, err := s.achievementRepo.GetReferences(studentIDs, status, limit, offset, summary)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	totalPages := int(total) / limit
 	if int(total)%limit != 0 {
@@ -114,6 +253,45 @@ func (s *AchievementService) GetAchievements(userID, roleName string, status str
 	return refs, pagination, nil
 }
 
+// GetAchievementsByCursor lists achievements visible to the user with keyset
+// pagination, which stays stable while new achievements are created. The
+// total is only counted when withTotal is set.
+func (s *AchievementService) GetAchievementsByCursor(userID, roleName, status string, cursor *model.Cursor, limit int, summary, withTotal bool) ([]*model.AchievementReference, *model.CursorPagination, error) {
+	studentIDs, scoped, err := s.scopeStudentIDs(userID, roleName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if scoped && len(studentIDs) == 0 {
+		meta := helper.NewCursorPagination(limit, cursor, false, nil, nil)
+		return []*model.AchievementReference{}, &meta, nil
+	}
+
+	refs, more, err := s.achievementRepo.ListReferencesByCursor(studentIDs, status, cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.achievementRepo.AttachAchievements(refs, summary); err != nil {
+		return nil, nil, err
+	}
+
+	var first, last *model.Cursor
+	if len(refs) > 0 {
+		first = &model.Cursor{CreatedAt: refs[0].CreatedAt, ID: refs[0].ID}
+		last = &model.Cursor{CreatedAt: refs[len(refs)-1].CreatedAt, ID: refs[len(refs)-1].ID}
+	}
+	meta := helper.NewCursorPagination(limit, cursor, more, first, last)
+
+	if withTotal {
+		total, err := s.achievementRepo.CountReferences(studentIDs, status)
+		if err != nil {
+			return nil, nil, err
+		}
+		meta.TotalItems = &total
+	}
+
+	return refs, &meta, nil
+}
+
 func (s *AchievementService) GetAchievementByID(id, userID, roleName string) (*model.AchievementReference, error) {
 	ref, err := s.achievementRepo.FindReferenceByID(id)
 	if err != nil {
@@ -124,45 +302,83 @@ func (s *AchievementService) GetAchievementByID(id, userID, roleName string) (*m
 	}
 
 	// Check permissions
+	if err := s.authorizeAccess(ref, userID, roleName); err != nil {
+		return nil, err
+	}
+
+	// Fetch achievement details
+	achievement, err := s.achievementRepo.FindMongoByID(ref.MongoAchievementID)
+	if err != nil {
+		return nil, err
+	}
+	ref.Achievement = achievement
+
+	comments, err := s.commentRepo.FindByAchievementID(ref.ID)
+	if err != nil {
+		return nil, err
+	}
+	ref.Comments = comments
+
+	approvals, err := s.approvalRepo.FindApprovalsByReference(ref.ID)
+	if err != nil {
+		return nil, err
+	}
+	ref.Approvals = approvals
+
+	if err := s.loadMembers(ref); err != nil {
+		return nil, err
+	}
+
+	// Likely duplicates are shown to reviewers only
+	if roleName != "Mahasiswa" {
+		if err := s.loadDuplicateCandidates(ref); err != nil {
+			return nil, err
+		}
+	}
+
+	return ref, nil
+}
+
+// authorizeAccess checks that the user may read the achievement: students
+// only those they take part in, advisors only those involving one of their
+// advisees, admins everything.
+func (s *AchievementService) authorizeAccess(ref *model.AchievementReference, userID, roleName string) error {
 	if roleName == "Mahasiswa" {
 		student, err := s.studentRepo.FindByUserID(userID)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if student == nil {
+			return errors.New("unauthorized")
 		}
-		if student == nil || student.ID != ref.StudentID {
-			return nil, errors.New("unauthorized")
+		isMember, err := s.isTeamMember(ref, student.ID)
+		if err != nil {
+			return err
+		}
+		if !isMember {
+			return errors.New("unauthorized")
 		}
 	} else if roleName == "Dosen Wali" {
-		lecturer, err := repository.NewLecturerRepository().FindByUserID(userID)
+		lecturer, err := s.lecturerRepo.FindByUserID(userID)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if lecturer != nil {
 			studentIDs, err := s.studentRepo.GetStudentsByAdvisorID(lecturer.ID)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			authorized := false
-			for _, sid := range studentIDs {
-				if sid == ref.StudentID {
-					authorized = true
-					break
-				}
+			authorized, err := s.memberRepo.InvolvesAnyStudent(ref.ID, ref.StudentID, studentIDs)
+			if err != nil {
+				return err
 			}
 			if !authorized {
-				return nil, errors.New("unauthorized")
+				return errors.New("unauthorized")
 			}
 		}
 	}
 
-	// Fetch achievement details
-	achievement, err := s.achievementRepo.FindMongoByID(ref.MongoAchievementID)
-	if err != nil {
-		return nil, err
-	}
-	ref.Achievement = achievement
-
-	return ref, nil
+	return nil
 }
 
 func (s *AchievementService) UpdateAchievement(id, userID string, req *model.UpdateAchievementRequest) error {
@@ -183,21 +399,186 @@ func (s *AchievementService) UpdateAchievement(id, userID string, req *model.Upd
 		return errors.New("unauthorized")
 	}
 
-	// Only allow update if status is draft or rejected
-	if ref.Status != "draft" && ref.Status != "rejected" {
+	if !containsStatus(editableStatuses, ref.Status) {
 		return errors.New("cannot update achievement in current status")
 	}
 
+	if err := s.requireDocumentStored(ref); err != nil {
+		return err
+	}
+
+	// The team is validated up front but only written once the document edit
+	// and its revision are saved
+	var team []*model.AchievementMember
+	if req.Members != nil {
+		if team, err = s.resolveTeam(student, req.Members); err != nil {
+			return err
+		}
+		if team == nil {
+			// An empty list removes the team
+			team = []*model.AchievementMember{}
+		}
+	}
+	rule := ""
+	if req.TeamPointRule != "" {
+		if rule, err = validateTeamPointRule(req.TeamPointRule); err != nil {
+			return err
+		}
+	}
+
+	// The current state is kept to roll the edit back when its revision
+	// cannot be recorded
+	previous, err := s.achievementRepo.FindMongoByID(ref.MongoAchievementID)
+	if err != nil {
+		return err
+	}
+
+	// Achievements created before revision tracking have no history yet,
+	// keep their current state as the baseline
+	latest, err := s.revisionRepo.LatestNumber(ref.MongoAchievementID)
+	if err != nil {
+		return err
+	}
+	if latest == 0 {
+		if err := s.recordRevision(previous, student.UserID, ref.Status, "baseline"); err != nil {
+			return err
+		}
+	}
+
+	tags, err := s.tagService.NormalizeTags(req.Tags)
+	if err != nil {
+		return err
+	}
+
 	// Update MongoDB
 	achievement := &model.Achievement{
 		Title:       req.Title,
 		Description: req.Description,
 		Details:     req.Details,
-		Tags:        req.Tags,
+		Tags:        tags,
 		Points:      req.Points,
 	}
 
-	return s.achievementRepo.UpdateMongo(ref.MongoAchievementID, achievement)
+	if err := s.achievementRepo.UpdateMongo(ref.MongoAchievementID, achievement); err != nil {
+		return err
+	}
+
+	// Every saved edit must have a revision, undo the edit when none could be
+	// recorded
+	updated, err := s.achievementRepo.FindMongoByID(ref.MongoAchievementID)
+	if err == nil {
+		err = s.recordRevision(updated, userID, ref.Status, "")
+	}
+	if err != nil {
+		if rollbackErr := s.achievementRepo.UpdateMongo(ref.MongoAchievementID, previous); rollbackErr != nil {
+			log.Printf("Achievement %s: rolling back edit without revision failed: %v", ref.ID, rollbackErr)
+		}
+		return err
+	}
+
+	if team != nil || rule != "" {
+		if err := s.achievementRepo.UpdateTeam(ref.ID, team, rule); err != nil {
+			return err
+		}
+	}
+
+	// A changed event date may move the achievement to another period
+	periodDate := ref.CreatedAt
+	if req.Details.EventDate != nil {
+		periodDate = *req.Details.EventDate
+	}
+	periodID, err := s.periodIDForDate(periodDate)
+	if err != nil {
+		return err
+	}
+	if !equalStringPointers(periodID, ref.PeriodID) {
+		return s.achievementRepo.SetReferencePeriod(ref.ID, periodID)
+	}
+	return nil
+}
+
+func (s *AchievementService) recordRevision(achievement *model.Achievement, authorID, status, note string) error {
+	revision := &model.AchievementRevision{
+		AchievementID: achievement.ID.Hex(),
+		AuthorID:      authorID,
+		Status:        status,
+		Note:          note,
+		Snapshot:      *achievement,
+	}
+	return s.revisionRepo.Create(revision)
+}
+
+func (s *AchievementService) GetRevisions(id, userID, roleName string) ([]*model.AchievementRevision, error) {
+	ref, err := s.achievementRepo.FindReferenceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, errors.New("achievement not found")
+	}
+
+	if err := s.authorizeAccess(ref, userID, roleName); err != nil {
+		return nil, err
+	}
+
+	return s.revisionRepo.FindByAchievementID(ref.MongoAchievementID)
+}
+
+// GetRevisionDiff compares two revisions of an achievement. When from or to
+// is zero it defaults to the previous and latest revision respectively.
+func (s *AchievementService) GetRevisionDiff(id, userID, roleName string, from, to int) (*model.RevisionDiff, error) {
+	ref, err := s.achievementRepo.FindReferenceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, errors.New("achievement not found")
+	}
+
+	if err := s.authorizeAccess(ref, userID, roleName); err != nil {
+		return nil, err
+	}
+
+	if to == 0 {
+		to, err = s.revisionRepo.LatestNumber(ref.MongoAchievementID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if from < 1 || to < 1 {
+		return nil, errors.New("not enough revisions to compare")
+	}
+
+	fromRevision, err := s.revisionRepo.FindByNumber(ref.MongoAchievementID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := s.revisionRepo.FindByNumber(ref.MongoAchievementID, to)
+	if err != nil {
+		return nil, err
+	}
+	if fromRevision == nil || toRevision == nil {
+		return nil, errors.New("revision not found")
+	}
+
+	// Bookkeeping fields change on every save, leave them out of the diff
+	oldSnapshot, newSnapshot := fromRevision.Snapshot, toRevision.Snapshot
+	oldSnapshot.UpdatedAt = newSnapshot.UpdatedAt
+
+	changes, err := helper.DiffFields(oldSnapshot, newSnapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.RevisionDiff{
+		AchievementID: id,
+		From:          from,
+		To:            to,
+		Changes:       changes,
+	}, nil
 }
 
 func (s *AchievementService) DeleteAchievement(id, userID string) error {
@@ -218,21 +599,59 @@ func (s *AchievementService) DeleteAchievement(id, userID string) error {
 		return errors.New("unauthorized")
 	}
 
-	// Only allow delete if status is draft
-	if ref.Status != "draft" {
-		return errors.New("can only delete draft achievements")
+	if !containsStatus(deletableStatuses, ref.Status) {
+		return errors.New("can only delete draft or withdrawn achievements")
+	}
+
+	// The achievement is only hidden, it can be restored until the retention
+	// job purges it from both stores
+	return s.achievementRepo.SoftDeleteReference(ref.ID, userID)
+}
+
+func (s *AchievementService) SubmitForVerification(id, userID string) error {
+	ref, err := s.achievementRepo.FindReferenceByID(id)
+	if err != nil {
+		return err
+	}
+	if ref == nil {
+		return errors.New("achievement not found")
 	}
 
-	// Delete from MongoDB
-	if err := s.achievementRepo.DeleteMongo(ref.MongoAchievementID); err != nil {
+	if err := s.requireDocumentStored(ref); err != nil {
 		return err
 	}
 
-	// Delete reference
-	return s.achievementRepo.DeleteReference(id)
+	if err := s.transition(ref, ActionSubmit, workflowActor{UserID: userID}); err != nil {
+		return err
+	}
+
+	// Check again, the content may have changed since it was created. The
+	// submission is already committed, a failed check is only logged.
+	achievement, err := s.achievementRepo.FindMongoByID(ref.MongoAchievementID)
+	if err == nil {
+		err = s.detectDuplicates(ref, achievement)
+	}
+	if err != nil {
+		log.Printf("Achievement %s: duplicate check after submit failed: %v", ref.ID, err)
+	}
+	return nil
 }
 
-func (s *AchievementService) SubmitForVerification(id, userID string) error {
+// requireDocumentStored rejects changes to an achievement whose document is
+// still waiting in the outbox. Its content does not exist yet and the
+// reference may still be removed when the write keeps failing.
+func (s *AchievementService) requireDocumentStored(ref *model.AchievementReference) error {
+	pending, err := s.outboxService.CreatePending(ref.ID)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return errors.New("achievement is still being saved, try again later")
+	}
+	return nil
+}
+
+func (s *AchievementService) WithdrawAchievement(id, userID string) error {
 	ref, err := s.achievementRepo.FindReferenceByID(id)
 	if err != nil {
 		return err
@@ -241,22 +660,47 @@ func (s *AchievementService) SubmitForVerification(id, userID string) error {
 		return errors.New("achievement not found")
 	}
 
-	student, err := s.studentRepo.FindByUserID(userID)
+	return s.transition(ref, ActionWithdraw, workflowActor{UserID: userID})
+}
+
+func (s *AchievementService) VerifyAchievement(id, userID, roleName string, req *model.VerifyAchievementRequest) error {
+	ref, err := s.achievementRepo.FindReferenceByID(id)
 	if err != nil {
 		return err
 	}
-	if student == nil || student.ID != ref.StudentID {
-		return errors.New("unauthorized")
+	if ref == nil {
+		return errors.New("achievement not found")
 	}
 
-	if ref.Status != "draft" && ref.Status != "rejected" {
-		return errors.New("achievement already submitted")
+	switch req.Action {
+	case ActionVerify, ActionReject, ActionRequestRevision:
+	default:
+		return errors.New("invalid action")
 	}
 
-	return s.achievementRepo.UpdateReferenceStatus(id, "submitted", nil, nil)
+	steps, err := s.approvalStepsFor(ref)
+	if err != nil {
+		return err
+	}
+
+	actor := workflowActor{UserID: userID, RoleName: roleName, Note: req.Note}
+	if ref.ApprovalStep < len(steps) {
+		actor.Step = &steps[ref.ApprovalStep]
+	}
+
+	// Only the last step of the chain makes the achievement verified
+	action := req.Action
+	if action == ActionVerify && ref.ApprovalStep+1 < len(steps) {
+		action = ActionApproveStep
+	}
+
+	return s.transition(ref, action, actor)
 }
 
-func (s *AchievementService) VerifyAchievement(id, userID string, req *model.VerifyAchievementRequest) error {
+// RevokeAchievement withdraws the verification of an achievement. The
+// reference keeps its original verification details and the revocation is
+// added to its status history and comment thread.
+func (s *AchievementService) RevokeAchievement(id, userID, roleName string, req *model.RevokeAchievementRequest) error {
 	ref, err := s.achievementRepo.FindReferenceByID(id)
 	if err != nil {
 		return err
@@ -265,43 +709,356 @@ func (s *AchievementService) VerifyAchievement(id, userID string, req *model.Ver
 		return errors.New("achievement not found")
 	}
 
-	if ref.Status != "submitted" {
-		return errors.New("achievement is not in submitted status")
+	actor := workflowActor{UserID: userID, RoleName: roleName, Note: req.Reason}
+	return s.transition(ref, ActionRevoke, actor)
+}
+
+// GetStatusHistory lists every status change of an achievement the user may see
+func (s *AchievementService) GetStatusHistory(id, userID, roleName string) ([]*model.AchievementStatusChange, error) {
+	ref, err := s.achievementRepo.FindReferenceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, errors.New("achievement not found")
 	}
 
-	if req.Action == "verify" {
-		return s.achievementRepo.UpdateReferenceStatus(id, "verified", &userID, nil)
-	} else if req.Action == "reject" {
-		return s.achievementRepo.UpdateReferenceStatus(id, "rejected", &userID, &req.Note)
+	if err := s.authorizeAccess(ref, userID, roleName); err != nil {
+		return nil, err
 	}
 
-	return errors.New("invalid action")
+	return s.achievementRepo.FindStatusHistory(ref.ID)
 }
 
-func (s *AchievementService) GetStatistics(userID, roleName string) (map[string]interface{}, error) {
-	var studentIDs []string
+// maxBulkVerifyItems limits how many achievements one bulk request may decide
+const maxBulkVerifyItems = 200
 
-	if roleName == "Mahasiswa" {
-		student, err := s.studentRepo.FindByUserID(userID)
+// BulkVerifyAchievements applies the same decision to many achievements. Each
+// item goes through VerifyAchievement on its own, so a failing item does not
+// stop the others.
+func (s *AchievementService) BulkVerifyAchievements(userID, roleName string, req *model.BulkVerifyAchievementRequest) (*model.BulkVerifyAchievementResponse, error) {
+	if len(req.IDs) == 0 {
+		return nil, errors.New("ids are required")
+	}
+	if len(req.IDs) > maxBulkVerifyItems {
+		return nil, fmt.Errorf("at most %d achievements can be processed at once", maxBulkVerifyItems)
+	}
+
+	response := &model.BulkVerifyAchievementResponse{Results: []model.BulkVerifyItemResult{}}
+	seen := make(map[string]bool)
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := model.BulkVerifyItemResult{ID: id, Success: true}
+		itemReq := &model.VerifyAchievementRequest{Action: req.Action, Note: req.Note}
+		if err := s.VerifyAchievement(id, userID, roleName, itemReq); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return response, nil
+}
+
+// reviewComment builds the comment carrying a reviewer's note into the
+// achievement's thread, linked to the revision that was reviewed. It returns
+// nil when there is no note.
+func (s *AchievementService) reviewComment(ref *model.AchievementReference, userID, kind, note string) (*model.AchievementComment, error) {
+	if strings.TrimSpace(note) == "" {
+		return nil, nil
+	}
+
+	comment := &model.AchievementComment{
+		AchievementRefID: ref.ID,
+		AuthorID:         userID,
+		Kind:             kind,
+		Body:             strings.TrimSpace(note),
+	}
+
+	latest, err := s.revisionRepo.LatestNumber(ref.MongoAchievementID)
+	if err != nil {
+		return nil, err
+	}
+	if latest > 0 {
+		comment.Revision = &latest
+	}
+
+	return comment, nil
+}
+
+// GetStatistics summarises the verified achievements visible to the user,
+// narrowed by the filter
+func (s *AchievementService) GetStatistics(userID, roleName string, filter model.StatisticsFilter) (*model.AchievementStatistics, error) {
+	studentIDs, scoped, err := s.scopeStudentIDs(userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if scoped && len(studentIDs) == 0 {
+		return helper.BuildStatistics(nil, nil), nil
+	}
+
+	return s.achievementRepo.GetStatistics(studentIDs, filter)
+}
+
+func (s *AchievementService) HandleCreateHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req model.CreateAchievementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	achievement, err := s.CreateAchievement(userID, &req)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Achievement created successfully", achievement)
+}
+
+func (s *AchievementService) HandleGetAllHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+	status := c.Query("status", "")
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	summary := c.Query("view") == "summary"
+
+	format, err := helper.ParseExportFormat(c)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if format != "" {
+		period, err := s.resolvePeriod(c.Query("period"))
 		if err != nil {
-			return nil, err
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 		}
-		if student != nil {
-			studentIDs = []string{student.ID}
+		table, err := s.achievementExportTable(userID, roleName, status, period)
+		if err != nil {
+			return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
 		}
-	} else if roleName == "Dosen Wali" {
-		lecturer, err := repository.NewLecturerRepository().FindByUserID(userID)
+		return s.sendExport(c, format, "prestasi", table)
+	}
+
+	if helper.UseCursorPagination(c) {
+		cursor, err := helper.DecodeCursor(c.Query("cursor"))
 		if err != nil {
-			return nil, err
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 		}
-		if lecturer != nil {
-			var err error
-			studentIDs, err = s.studentRepo.GetStudentsByAdvisorID(lecturer.ID)
-			if err != nil {
-				return nil, err
-			}
+		if limit < 1 || limit > 100 {
+			limit = 10
 		}
+
+		achievements, meta, err := s.GetAchievementsByCursor(userID, roleName, status, cursor, limit, summary, c.Query("include_total") == "true")
+		if err != nil {
+			return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return helper.CursorPaginatedResponse(c, achievements, *meta)
+	}
+
+	achievements, pagination, err := s.GetAchievements(userID, roleName, status, page, limit, summary)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.PaginatedResponse(c, achievements, *pagination)
+}
+
+func (s *AchievementService) HandleGetByIDHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	achievement, err := s.GetAchievementByID(id, userID, roleName)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Achievement retrieved", achievement)
+}
+
+func (s *AchievementService) HandleUpdateHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+
+	var req model.UpdateAchievementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := s.UpdateAchievement(id, userID, &req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Achievement updated successfully", nil)
+}
+
+func (s *AchievementService) HandleDeleteHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+
+	if err := s.DeleteAchievement(id, userID); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Achievement deleted successfully", nil)
+}
+
+func (s *AchievementService) HandleSubmitHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+
+	if err := s.SubmitForVerification(id, userID); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Achievement submitted for verification", nil)
+}
+
+func (s *AchievementService) HandleWithdrawHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+
+	if err := s.WithdrawAchievement(id, userID); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Achievement withdrawn", nil)
+}
+
+func (s *AchievementService) HandleVerifyHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	var req model.VerifyAchievementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := s.VerifyAchievement(id, userID, roleName, &req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	message := "Achievement verified successfully"
+	if req.Action == ActionReject {
+		message = "Achievement rejected"
+	} else if req.Action == ActionRequestRevision {
+		message = "Revision requested"
+	}
+
+	return helper.SuccessResponse(c, message, nil)
+}
+
+func (s *AchievementService) HandleRevokeHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	var req model.RevokeAchievementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := s.RevokeAchievement(id, userID, roleName, &req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Achievement revoked", nil)
+}
+
+func (s *AchievementService) HandleGetStatusHistoryHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	history, err := s.GetStatusHistory(id, userID, roleName)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Status history retrieved", history)
+}
+
+func (s *AchievementService) HandleBulkVerifyHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	var req model.BulkVerifyAchievementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	result, err := s.BulkVerifyAchievements(userID, roleName, &req)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Bulk verification processed", result)
+}
+
+func (s *AchievementService) HandleGetRevisionsHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	revisions, err := s.GetRevisions(id, userID, roleName)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Revisions retrieved", revisions)
+}
+
+func (s *AchievementService) HandleGetRevisionDiffHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+	from, _ := strconv.Atoi(c.Query("from", "0"))
+	to, _ := strconv.Atoi(c.Query("to", "0"))
+
+	diff, err := s.GetRevisionDiff(id, userID, roleName, from, to)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Revision diff retrieved", diff)
+}
+
+func (s *AchievementService) HandleStatisticsHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	format, err := helper.ParseExportFormat(c)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	period, err := s.resolvePeriod(c.Query("period"))
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	filter := model.StatisticsFilter{IncludeExpired: c.Query("include_expired") == "true"}
+	if period != nil {
+		filter.PeriodID = period.ID
+	}
+
+	stats, err := s.GetStatistics(userID, roleName, filter)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if format != "" {
+		return s.sendExport(c, format, "statistik-prestasi", statisticsExportTable(stats, period))
 	}
 
-	return s.achievementRepo.GetStatistics(studentIDs)
+	return helper.SuccessResponse(c, "Statistics retrieved", stats)
 }
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"projek_uas/app/model"
+	"projek_uas/helper"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// resolveTeam turns the members listed by NIM into team rows. The owner is
+// always part of the team, as leader unless another member is named leader,
+// and is confirmed right away. Returns nil when no members are listed.
+func (s *AchievementService) resolveTeam(owner *model.Student, inputs []model.AchievementMemberInput) ([]*model.AchievementMember, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	ownerMember := &model.AchievementMember{
+		StudentID:   owner.ID,
+		Role:        model.MemberRoleLeader,
+		Status:      model.MemberStatusConfirmed,
+		RespondedAt: &now,
+	}
+	members := []*model.AchievementMember{ownerMember}
+
+	seen := map[string]bool{}
+	leaders := 0
+	for _, input := range inputs {
+		nim := strings.TrimSpace(input.StudentNumber)
+		if nim == "" {
+			return nil, errors.New("student_number is required for every member")
+		}
+		if seen[nim] {
+			return nil, fmt.Errorf("student %s is listed more than once", nim)
+		}
+		seen[nim] = true
+
+		role := input.Role
+		if role == "" {
+			role = model.MemberRoleMember
+		}
+		if role != model.MemberRoleLeader && role != model.MemberRoleMember {
+			return nil, fmt.Errorf("invalid role %q, must be leader or member", input.Role)
+		}
+		if role == model.MemberRoleLeader {
+			leaders++
+		}
+
+		if nim == owner.StudentID {
+			ownerMember.Role = role
+			continue
+		}
+
+		student, err := s.studentRepo.FindByStudentNumber(nim)
+		if err != nil {
+			return nil, err
+		}
+		if student == nil {
+			return nil, fmt.Errorf("student %s not found", nim)
+		}
+
+		members = append(members, &model.AchievementMember{
+			StudentID: student.ID,
+			Role:      role,
+			Status:    model.MemberStatusPending,
+		})
+	}
+
+	if leaders > 1 {
+		return nil, errors.New("a team can only have one leader")
+	}
+	if !seen[owner.StudentID] && leaders == 1 {
+		ownerMember.Role = model.MemberRoleMember
+	}
+	if seen[owner.StudentID] && leaders == 0 {
+		return nil, errors.New("a team needs a leader")
+	}
+
+	return members, nil
+}
+
+func validateTeamPointRule(rule string) (string, error) {
+	switch rule {
+	case "":
+		return model.TeamPointRuleFull, nil
+	case model.TeamPointRuleFull, model.TeamPointRuleEqual:
+		return rule, nil
+	}
+	return "", fmt.Errorf("invalid team_point_rule %q, must be full or equal", rule)
+}
+
+// loadMembers attaches the team to the reference together with the points
+// each member is credited. Declined members are not credited.
+func (s *AchievementService) loadMembers(ref *model.AchievementReference) error {
+	members, err := s.memberRepo.FindByReference(ref.ID)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	active := 0
+	for _, member := range members {
+		if member.Status != model.MemberStatusDeclined {
+			active++
+		}
+	}
+
+	points := 0
+	if ref.Achievement != nil {
+		points = ref.Achievement.Points
+	}
+	for _, member := range members {
+		if member.Status != model.MemberStatusDeclined {
+			member.Points = model.MemberPoints(points, ref.TeamPointRule, active)
+		}
+	}
+
+	ref.Members = members
+	return nil
+}
+
+// isTeamMember reports whether the student takes part in the achievement,
+// either as its owner or as a member who has not declined.
+func (s *AchievementService) isTeamMember(ref *model.AchievementReference, studentID string) (bool, error) {
+	if ref.StudentID == studentID {
+		return true, nil
+	}
+
+	member, err := s.memberRepo.FindMember(ref.ID, studentID)
+	if err != nil {
+		return false, err
+	}
+	return member != nil && member.Status != model.MemberStatusDeclined, nil
+}
+
+// RespondToTeamInvitation lets a co-member confirm or decline taking part in
+// a team achievement
+func (s *AchievementService) RespondToTeamInvitation(id, userID string, confirm bool) error {
+	ref, err := s.achievementRepo.FindReferenceByID(id)
+	if err != nil {
+		return err
+	}
+	if ref == nil {
+		return errors.New("achievement not found")
+	}
+
+	student, err := s.studentRepo.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if student == nil {
+		return errors.New("student profile not found")
+	}
+
+	status := model.MemberStatusDeclined
+	if confirm {
+		status = model.MemberStatusConfirmed
+	}
+
+	if err := s.memberRepo.RespondToInvitation(ref.ID, student.ID, status); err != nil {
+		return err
+	}
+
+	// The answer changes how the points of the team are split
+	s.leaderboards.invalidate()
+	return nil
+}
+
+func (s *AchievementService) HandleConfirmMembershipHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+
+	if err := s.RespondToTeamInvitation(id, userID, true); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Team membership confirmed", nil)
+}
+
+func (s *AchievementService) HandleDeclineMembershipHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+
+	if err := s.RespondToTeamInvitation(id, userID, false); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Team membership declined", nil)
+}
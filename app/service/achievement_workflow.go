@@ -0,0 +1,407 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"projek_uas/app/model"
+	"strings"
+)
+
+// Workflow actions accepted by the submit, withdraw and verify endpoints
+const (
+	ActionSubmit          = "submit"
+	ActionWithdraw        = "withdraw"
+	ActionVerify          = "verify"
+	ActionReject          = "reject"
+	ActionRequestRevision = "request_revision"
+	ActionRevoke          = "revoke"
+
+	// ActionApproveStep is used internally when a verify decision completes
+	// an approval step that is not the last one in the chain
+	ActionApproveStep = "approve_step"
+)
+
+// workflowActor is the user attempting a transition. Step is the approval
+// step being decided for review actions.
+type workflowActor struct {
+	UserID   string
+	RoleName string
+	Note     string
+	Step     *model.ApprovalStep
+}
+
+// achievementTransition moves an achievement reference from one of the From
+// statuses to To, provided its guard allows the actor to do so.
+type achievementTransition struct {
+	From  []string
+	To    string
+	Guard func(s *AchievementService, ref *model.AchievementReference, actor workflowActor) error
+}
+
+// achievementTransitions is the verification state machine:
+//
+//	draft, rejected, needs_revision, withdrawn --submit--> submitted
+//	submitted --withdraw--> withdrawn
+//	submitted --approve_step--> submitted (intermediate approval step)
+//	submitted --verify--> verified (final approval step)
+//	submitted --reject--> rejected
+//	submitted --request_revision--> needs_revision
+//	verified --revoke--> revoked (admin only, final)
+var achievementTransitions = map[string]achievementTransition{
+	ActionSubmit: {
+		From:  []string{model.StatusDraft, model.StatusRejected, model.StatusNeedsRevision, model.StatusWithdrawn},
+		To:    model.StatusSubmitted,
+		Guard: (*AchievementService).guardSubmit,
+	},
+	ActionWithdraw: {
+		From:  []string{model.StatusSubmitted},
+		To:    model.StatusWithdrawn,
+		Guard: (*AchievementService).guardWithdraw,
+	},
+	ActionApproveStep: {
+		From:  []string{model.StatusSubmitted},
+		To:    model.StatusSubmitted,
+		Guard: (*AchievementService).guardVerify,
+	},
+	ActionVerify: {
+		From:  []string{model.StatusSubmitted},
+		To:    model.StatusVerified,
+		Guard: (*AchievementService).guardVerify,
+	},
+	ActionReject: {
+		From:  []string{model.StatusSubmitted},
+		To:    model.StatusRejected,
+		Guard: (*AchievementService).guardReject,
+	},
+	ActionRequestRevision: {
+		From:  []string{model.StatusSubmitted},
+		To:    model.StatusNeedsRevision,
+		Guard: (*AchievementService).guardRequestRevision,
+	},
+	ActionRevoke: {
+		From:  []string{model.StatusVerified},
+		To:    model.StatusRevoked,
+		Guard: (*AchievementService).guardRevoke,
+	},
+}
+
+// editableStatuses are the statuses in which the owner may change the content
+var editableStatuses = []string{model.StatusDraft, model.StatusRejected, model.StatusNeedsRevision, model.StatusWithdrawn}
+
+// deletableStatuses are the statuses in which the owner may delete the achievement
+var deletableStatuses = []string{model.StatusDraft, model.StatusWithdrawn}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// reviewCommentKinds maps the statuses a reviewer's note is posted for to
+// the kind of comment it becomes
+var reviewCommentKinds = map[string]string{
+	model.StatusRejected:      model.CommentKindRejection,
+	model.StatusNeedsRevision: model.CommentKindRevisionRequest,
+	model.StatusRevoked:       model.CommentKindRevocation,
+}
+
+// transition applies a workflow action to the reference. The new status,
+// its history entry, the approval and the reviewer's comment are written in
+// one transaction that fails when a concurrent request moved the reference
+// first.
+func (s *AchievementService) transition(ref *model.AchievementReference, action string, actor workflowActor) error {
+	t, ok := achievementTransitions[action]
+	if !ok {
+		return errors.New("invalid action")
+	}
+
+	if !containsStatus(t.From, ref.Status) {
+		return fmt.Errorf("cannot %s achievement in %s status", strings.ReplaceAll(action, "_", " "), ref.Status)
+	}
+
+	if err := t.Guard(s, ref, actor); err != nil {
+		return err
+	}
+
+	transition := &model.StatusTransition{
+		AchievementRefID: ref.ID,
+		FromStatus:       ref.Status,
+		ToStatus:         t.To,
+		FromStep:         ref.ApprovalStep,
+		Change:           statusChange(ref, action, t.To, actor),
+	}
+	if t.To == model.StatusVerified || t.To == model.StatusRejected || t.To == model.StatusNeedsRevision || t.To == model.StatusRevoked {
+		transition.ReviewerID = &actor.UserID
+	}
+	if t.To == model.StatusRejected || t.To == model.StatusRevoked {
+		transition.Note = &actor.Note
+	}
+	if t.To == model.StatusSubmitted {
+		chain, err := s.approvalChainFor(ref)
+		if err != nil {
+			return err
+		}
+		if chain.ID != "" {
+			transition.ApprovalChainID = &chain.ID
+		}
+		transition.ApprovalSteps = chain.Steps
+	}
+	if actor.Step != nil {
+		transition.Approval = approvalFor(ref, action, actor)
+	}
+	if kind, ok := reviewCommentKinds[t.To]; ok {
+		comment, err := s.reviewComment(ref, actor.UserID, kind, actor.Note)
+		if err != nil {
+			return err
+		}
+		transition.Comment = comment
+	}
+
+	if err := s.achievementRepo.ApplyTransition(transition); err != nil {
+		return err
+	}
+
+	// Rankings only count verified achievements
+	if t.To == model.StatusVerified || ref.Status == model.StatusVerified {
+		s.leaderboards.invalidate()
+	}
+
+	// The revoke is committed, a failed notice must not report it as failed
+	if t.To == model.StatusRevoked {
+		if err := s.notifyRevoked(ref, actor.Note); err != nil {
+			log.Printf("Achievement %s: revoke notice not sent: %v", ref.ID, err)
+		}
+	}
+	return nil
+}
+
+// statusChange builds the status history entry of the transition. Actions
+// that keep the status, such as intermediate approval steps, are recorded
+// in the approvals instead and get none.
+func statusChange(ref *model.AchievementReference, action, to string, actor workflowActor) *model.AchievementStatusChange {
+	if to == ref.Status {
+		return nil
+	}
+
+	change := &model.AchievementStatusChange{
+		AchievementRefID: ref.ID,
+		FromStatus:       ref.Status,
+		ToStatus:         to,
+		Action:           action,
+		ActorID:          &actor.UserID,
+	}
+	if note := strings.TrimSpace(actor.Note); note != "" {
+		change.Note = &note
+	}
+
+	return change
+}
+
+// notifyRevoked tells the owner and the confirmed team members that the
+// achievement's verification was revoked
+func (s *AchievementService) notifyRevoked(ref *model.AchievementReference, reason string) error {
+	studentIDs := []string{ref.StudentID}
+	members, err := s.memberRepo.FindByReference(ref.ID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member.StudentID != ref.StudentID && member.Status != model.MemberStatusDeclined {
+			studentIDs = append(studentIDs, member.StudentID)
+		}
+	}
+
+	students, err := s.studentRepo.FindByIDs(studentIDs)
+	if err != nil {
+		return err
+	}
+	for _, student := range students {
+		err := s.notificationService.Notify(
+			student.UserID, model.NotificationRevoked, "Verifikasi prestasi dicabut",
+			fmt.Sprintf("Verifikasi prestasi Anda dicabut oleh admin. Alasan: %s", strings.TrimSpace(reason)),
+			&ref.ID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// approvalFor builds the decision taken on the current approval step
+func approvalFor(ref *model.AchievementReference, action string, actor workflowActor) *model.AchievementApproval {
+	approval := &model.AchievementApproval{
+		AchievementRefID: ref.ID,
+		StepOrder:        actor.Step.StepOrder,
+		StepName:         actor.Step.Name,
+		ApproverID:       actor.UserID,
+		SubmittedAt:      ref.SubmittedAt,
+	}
+
+	switch action {
+	case ActionVerify, ActionApproveStep:
+		approval.Action = model.ApprovalActionApprove
+	case ActionReject:
+		approval.Action = model.ApprovalActionReject
+	case ActionRequestRevision:
+		approval.Action = model.ApprovalActionRequestRevision
+	}
+
+	if note := strings.TrimSpace(actor.Note); note != "" {
+		approval.Note = &note
+	}
+
+	return approval
+}
+
+func (s *AchievementService) guardSubmit(ref *model.AchievementReference, actor workflowActor) error {
+	if err := s.requireOwner(ref, actor.UserID); err != nil {
+		return err
+	}
+
+	pending, err := s.memberRepo.CountPending(ref.ID)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d team member(s) have not confirmed yet", pending)
+	}
+	return nil
+}
+
+func (s *AchievementService) guardWithdraw(ref *model.AchievementReference, actor workflowActor) error {
+	return s.requireOwner(ref, actor.UserID)
+}
+
+func (s *AchievementService) guardVerify(ref *model.AchievementReference, actor workflowActor) error {
+	return s.requireStepApprover(ref, actor)
+}
+
+func (s *AchievementService) guardReject(ref *model.AchievementReference, actor workflowActor) error {
+	return s.requireStepApprover(ref, actor)
+}
+
+func (s *AchievementService) guardRequestRevision(ref *model.AchievementReference, actor workflowActor) error {
+	if err := s.requireStepApprover(ref, actor); err != nil {
+		return err
+	}
+	if strings.TrimSpace(actor.Note) == "" {
+		return errors.New("a note is required when requesting a revision")
+	}
+	return nil
+}
+
+func (s *AchievementService) guardRevoke(ref *model.AchievementReference, actor workflowActor) error {
+	if actor.RoleName != "Admin" {
+		return errors.New("only an admin can revoke a verified achievement")
+	}
+	if strings.TrimSpace(actor.Note) == "" {
+		return errors.New("a reason is required to revoke an achievement")
+	}
+	return nil
+}
+
+// requireOwner allows only the student who owns the achievement
+func (s *AchievementService) requireOwner(ref *model.AchievementReference, userID string) error {
+	student, err := s.studentRepo.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if student == nil || student.ID != ref.StudentID {
+		return errors.New("unauthorized")
+	}
+	return nil
+}
+
+// requireStepApprover allows users whose role is required by the current
+// approval step. A Dosen Wali must also be the advisor of the owning student.
+func (s *AchievementService) requireStepApprover(ref *model.AchievementReference, actor workflowActor) error {
+	if actor.Step == nil {
+		return errors.New("approval chain already completed")
+	}
+
+	allowed := false
+	for _, role := range actor.Step.AllowedRoles {
+		if role == actor.RoleName {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("step %q must be approved by %s", actor.Step.Name, strings.Join(actor.Step.AllowedRoles, " or "))
+	}
+
+	if actor.RoleName == "Dosen Wali" {
+		return s.requireAdvisor(ref, actor.UserID)
+	}
+
+	return nil
+}
+
+// requireAdvisor allows only the advisor of the owning student
+func (s *AchievementService) requireAdvisor(ref *model.AchievementReference, userID string) error {
+	lecturer, err := s.lecturerRepo.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if lecturer == nil {
+		return errors.New("unauthorized")
+	}
+
+	student, err := s.studentRepo.FindByID(ref.StudentID)
+	if err != nil {
+		return err
+	}
+	if student == nil || student.AdvisorID == nil || *student.AdvisorID != lecturer.ID {
+		return errors.New("unauthorized")
+	}
+
+	return nil
+}
+
+// defaultApprovalChain is used when no configured chain matches: a single
+// step decided by the student's advisor or an admin.
+var defaultApprovalChain = model.ApprovalChain{
+	Name: "Default",
+	Steps: []model.ApprovalStep{
+		{StepOrder: 1, Name: "Dosen Wali", AllowedRoles: []string{"Dosen Wali", "Admin"}},
+	},
+}
+
+// approvalStepsFor returns the approval steps the submitted achievement is
+// decided by: the chain stored when it was submitted, so later changes to
+// the chain do not affect it. Submissions made before chains were stored
+// fall back to the chain that applies now.
+func (s *AchievementService) approvalStepsFor(ref *model.AchievementReference) ([]model.ApprovalStep, error) {
+	if len(ref.ApprovalSteps) > 0 {
+		return ref.ApprovalSteps, nil
+	}
+
+	chain, err := s.approvalChainFor(ref)
+	if err != nil {
+		return nil, err
+	}
+	return chain.Steps, nil
+}
+
+// approvalChainFor returns the approval chain that applies to the achievement
+func (s *AchievementService) approvalChainFor(ref *model.AchievementReference) (*model.ApprovalChain, error) {
+	achievement, err := s.achievementRepo.FindMongoByID(ref.MongoAchievementID)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := s.approvalRepo.FindChainFor(achievement.AchievementType, achievement.Details.CompetitionLevel)
+	if err != nil {
+		return nil, err
+	}
+	if chain == nil || len(chain.Steps) == 0 {
+		return &defaultApprovalChain, nil
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ApprovalService struct {
+	approvalRepo *repository.ApprovalRepository
+	userRepo     *repository.UserRepository
+}
+
+func NewApprovalService(approvalRepo *repository.ApprovalRepository, userRepo *repository.UserRepository) *ApprovalService {
+	return &ApprovalService{
+		approvalRepo: approvalRepo,
+		userRepo:     userRepo,
+	}
+}
+
+func (s *ApprovalService) GetChains() ([]*model.ApprovalChain, error) {
+	return s.approvalRepo.GetChains()
+}
+
+func (s *ApprovalService) CreateChain(req *model.CreateApprovalChainRequest) (*model.ApprovalChain, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, errors.New("chain name is required")
+	}
+	if len(req.Steps) == 0 {
+		return nil, errors.New("at least one step is required")
+	}
+
+	chain := &model.ApprovalChain{
+		Name:             strings.TrimSpace(req.Name),
+		AchievementType:  req.AchievementType,
+		CompetitionLevel: req.CompetitionLevel,
+	}
+
+	// Steps are ordered as given in the request
+	for i, step := range req.Steps {
+		if strings.TrimSpace(step.Name) == "" {
+			return nil, fmt.Errorf("step %d: name is required", i+1)
+		}
+		if len(step.AllowedRoles) == 0 {
+			return nil, fmt.Errorf("step %d: at least one role is required", i+1)
+		}
+		for _, roleName := range step.AllowedRoles {
+			role, err := s.userRepo.GetRoleByName(roleName)
+			if err != nil {
+				return nil, err
+			}
+			if role == nil {
+				return nil, fmt.Errorf("step %d: invalid role %q", i+1, roleName)
+			}
+		}
+
+		chain.Steps = append(chain.Steps, model.ApprovalStep{
+			StepOrder:    i + 1,
+			Name:         strings.TrimSpace(step.Name),
+			AllowedRoles: step.AllowedRoles,
+		})
+	}
+
+	if err := s.approvalRepo.CreateChain(chain); err != nil {
+		return nil, err
+	}
+
+	return chain, nil
+}
+
+func (s *ApprovalService) DeleteChain(id string) error {
+	return s.approvalRepo.DeleteChain(id)
+}
+
+func (s *ApprovalService) HandleGetChainsHTTP(c *fiber.Ctx) error {
+	chains, err := s.GetChains()
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Approval chains retrieved", chains)
+}
+
+func (s *ApprovalService) HandleCreateChainHTTP(c *fiber.Ctx) error {
+	var req model.CreateApprovalChainRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	chain, err := s.CreateChain(&req)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Approval chain created successfully", chain)
+}
+
+func (s *ApprovalService) HandleDeleteChainHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if err := s.DeleteChain(id); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Approval chain deleted successfully", nil)
+}
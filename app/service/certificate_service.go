@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errCertificateNotValid is returned for every failed public verification,
+// so the response does not reveal whether an achievement exists
+var errCertificateNotValid = errors.New("certificate is not valid")
+
+type CertificateService struct {
+	achievementRepo    *repository.AchievementRepository
+	studentRepo        *repository.StudentRepository
+	userRepo           *repository.UserRepository
+	achievementService *AchievementService
+	secret             string
+	publicURL          string
+	institutionName    string
+}
+
+func NewCertificateService(
+	achievementRepo *repository.AchievementRepository,
+	studentRepo *repository.StudentRepository,
+	userRepo *repository.UserRepository,
+	achievementService *AchievementService,
+	secret, publicURL, institutionName string,
+) *CertificateService {
+	return &CertificateService{
+		achievementRepo:    achievementRepo,
+		studentRepo:        studentRepo,
+		userRepo:           userRepo,
+		achievementService: achievementService,
+		secret:             secret,
+		publicURL:          strings.TrimRight(publicURL, "/"),
+		institutionName:    institutionName,
+	}
+}
+
+// GetCertificate prepares the certificate of a verified achievement for a
+// user allowed to see the achievement
+func (s *CertificateService) GetCertificate(id, userID, roleName string) (*model.Certificate, error) {
+	ref, err := s.achievementRepo.FindReferenceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, errors.New("achievement not found")
+	}
+	if err := s.achievementService.authorizeAccess(ref, userID, roleName); err != nil {
+		return nil, err
+	}
+	if ref.Status != model.StatusVerified || ref.VerifiedAt == nil {
+		return nil, errors.New("only verified achievements have a certificate")
+	}
+
+	token, err := helper.SignCertificateToken(s.secret, ref.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := s.publicView(ref)
+	if err != nil {
+		return nil, err
+	}
+	students, err := s.studentRepo.FindByIDs([]string{ref.StudentID})
+	if err != nil {
+		return nil, err
+	}
+	student, ok := students[ref.StudentID]
+	if !ok {
+		return nil, errors.New("student not found")
+	}
+
+	return &model.Certificate{
+		AchievementID:   ref.ID,
+		Institution:     s.institutionName,
+		StudentName:     view.StudentName,
+		StudentNumber:   student.StudentID,
+		ProgramStudy:    view.ProgramStudy,
+		Title:           view.Title,
+		Description:     describeForSKPI(ref.Achievement),
+		VerifiedAt:      view.VerifiedAt,
+		VerifiedBy:      view.VerifiedBy,
+		VerificationURL: fmt.Sprintf("%s/api/v1/public/verify/%s", s.publicURL, token),
+	}, nil
+}
+
+// VerifyToken checks a scanned certificate. It only succeeds while the
+// achievement is still verified.
+func (s *CertificateService) VerifyToken(token string) (*model.PublicVerification, error) {
+	id, err := helper.VerifyCertificateToken(s.secret, token)
+	if err != nil {
+		return nil, errCertificateNotValid
+	}
+
+	ref, err := s.achievementRepo.FindReferenceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil || ref.Status != model.StatusVerified || ref.VerifiedAt == nil {
+		return nil, errCertificateNotValid
+	}
+
+	return s.publicView(ref)
+}
+
+// publicView loads the few fields that may be shown without logging in
+func (s *CertificateService) publicView(ref *model.AchievementReference) (*model.PublicVerification, error) {
+	if err := s.achievementRepo.AttachAchievements([]*model.AchievementReference{ref}, true); err != nil {
+		return nil, err
+	}
+	if ref.Achievement == nil {
+		return nil, errCertificateNotValid
+	}
+
+	students, err := s.studentRepo.FindByIDs([]string{ref.StudentID})
+	if err != nil {
+		return nil, err
+	}
+	student, ok := students[ref.StudentID]
+	if !ok {
+		return nil, errCertificateNotValid
+	}
+
+	view := &model.PublicVerification{
+		Institution:      s.institutionName,
+		StudentName:      student.User.FullName,
+		ProgramStudy:     student.ProgramStudy,
+		Title:            ref.Achievement.Title,
+		AchievementType:  ref.Achievement.AchievementType,
+		CompetitionLevel: ref.Achievement.Details.CompetitionLevel,
+		EventDate:        ref.Achievement.Details.EventDate,
+		VerifiedAt:       *ref.VerifiedAt,
+		ValidUntil:       ref.Achievement.Details.ValidUntil,
+		Expired:          ref.ExpiredAt != nil,
+	}
+
+	if ref.VerifiedBy != nil {
+		verifier, err := s.userRepo.FindByID(*ref.VerifiedBy)
+		if err != nil {
+			return nil, err
+		}
+		if verifier != nil {
+			view.VerifiedBy = verifier.FullName
+		}
+	}
+
+	return view, nil
+}
+
+func (s *CertificateService) HandleGetCertificateHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	certificate, err := s.GetCertificate(id, userID, roleName)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var buffer bytes.Buffer
+	if err := helper.WriteCertificatePDF(&buffer, certificate); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="sertifikat-%s.pdf"`, certificate.AchievementID))
+	return c.Send(buffer.Bytes())
+}
+
+func (s *CertificateService) HandleVerifyHTTP(c *fiber.Ctx) error {
+	view, err := s.VerifyToken(c.Params("token"))
+	if err == errCertificateNotValid {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "verification is unavailable, please try again later")
+	}
+
+	return helper.SuccessResponse(c, "Certificate is valid", view)
+}
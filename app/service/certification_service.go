@@ -0,0 +1,249 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CertificationService tracks the validity of verified certifications. A
+// certification is marked expired once its validUntil passes and its owner
+// is notified NoticeBefore ahead of that, unless a renewal is already
+// verified.
+type CertificationService struct {
+	achievementRepo     *repository.AchievementRepository
+	studentRepo         *repository.StudentRepository
+	notificationService *NotificationService
+	achievementService  *AchievementService
+	noticeBefore        time.Duration
+}
+
+func NewCertificationService(
+	achievementRepo *repository.AchievementRepository,
+	studentRepo *repository.StudentRepository,
+	notificationService *NotificationService,
+	achievementService *AchievementService,
+	noticeBefore time.Duration,
+) *CertificationService {
+	return &CertificationService{
+		achievementRepo:     achievementRepo,
+		studentRepo:         studentRepo,
+		notificationService: notificationService,
+		achievementService:  achievementService,
+		noticeBefore:        noticeBefore,
+	}
+}
+
+// Start runs the expiry check on the given interval until stopped
+func (s *CertificationService) Start(interval time.Duration) func() {
+	return StartScheduler("certification-expiry", interval, s.RunOnce)
+}
+
+// RunOnce marks certifications that passed their validUntil as expired and
+// sends the notices that are due. A notice that cannot be sent is logged and
+// the run goes on with the other certifications.
+func (s *CertificationService) RunOnce() error {
+	now := time.Now()
+
+	// Certifications already marked expired are done, only the others are
+	// looked at so the scan does not grow with every expired certification
+	candidates, err := s.achievementRepo.FindUnexpiredVerifiedMongoIDs()
+	if err != nil {
+		return err
+	}
+	documents, err := s.achievementRepo.FindMongoCertificationsValidUntil(candidates, now.Add(s.noticeBefore))
+	if err != nil {
+		return err
+	}
+	if len(documents) == 0 {
+		return nil
+	}
+
+	mongoIDs := make([]string, 0, len(documents))
+	for _, document := range documents {
+		mongoIDs = append(mongoIDs, document.ID.Hex())
+	}
+	refs, err := s.achievementRepo.FindReferencesByMongoIDs(mongoIDs)
+	if err != nil {
+		return err
+	}
+
+	refIDs := make([]string, 0, len(refs))
+	studentIDs := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		refIDs = append(refIDs, ref.ID)
+		studentIDs = append(studentIDs, ref.StudentID)
+	}
+	renewed, err := s.achievementRepo.FindRenewedReferenceIDs(refIDs)
+	if err != nil {
+		return err
+	}
+	students, err := s.studentRepo.FindByIDs(studentIDs)
+	if err != nil {
+		return err
+	}
+
+	expiredAny := false
+	for _, document := range documents {
+		ref, ok := refs[document.ID.Hex()]
+		if !ok || ref.Status != model.StatusVerified || ref.ExpiredAt != nil {
+			continue
+		}
+		student, ok := students[ref.StudentID]
+		if !ok {
+			continue
+		}
+		validUntil := *document.Details.ValidUntil
+
+		if !validUntil.After(now) {
+			marked, err := s.achievementRepo.MarkExpired(ref.ID, validUntil)
+			if err != nil {
+				return err
+			}
+			if !marked {
+				continue
+			}
+			expiredAny = true
+			if renewed[ref.ID] {
+				continue
+			}
+
+			err = s.notificationService.Notify(
+				student.UserID, model.NotificationExpired, "Sertifikasi kedaluwarsa",
+				fmt.Sprintf("Sertifikasi %s berakhir pada %s dan tidak lagi dihitung dalam laporan. Tautkan sertifikasi perpanjangan bila sudah diperbarui.",
+					document.Title, validUntil.Format("2006-01-02")),
+				&ref.ID,
+			)
+			if err != nil {
+				log.Printf("Achievement %s: expiry notice not sent: %v", ref.ID, err)
+			}
+			continue
+		}
+
+		if renewed[ref.ID] {
+			continue
+		}
+		claimed, err := s.achievementRepo.ClaimExpiryNotice(ref.ID)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		err = s.notificationService.Notify(
+			student.UserID, model.NotificationExpiring, "Sertifikasi akan kedaluwarsa",
+			fmt.Sprintf("Sertifikasi %s berlaku sampai %s. Segera perpanjang dan tautkan prestasi perpanjangannya.",
+				document.Title, validUntil.Format("2006-01-02")),
+			&ref.ID,
+		)
+		if err != nil {
+			log.Printf("Achievement %s: expiring notice not sent: %v", ref.ID, err)
+		}
+	}
+
+	// Rankings leave out expired certifications by default
+	if expiredAny {
+		s.achievementService.leaderboards.invalidate()
+	}
+	return nil
+}
+
+// SetRenewalOf links a certification to the older certification of the same
+// student it renews, or removes the link when renewalOf is nil. Only the
+// owner may link.
+func (s *CertificationService) SetRenewalOf(id, userID string, renewalOf *string) error {
+	ref, err := s.achievementRepo.FindReferenceByID(id)
+	if err != nil {
+		return err
+	}
+	if ref == nil {
+		return errors.New("achievement not found")
+	}
+	if err := s.achievementService.requireOwner(ref, userID); err != nil {
+		return err
+	}
+
+	if renewalOf != nil {
+		if err := s.validateRenewal(ref, *renewalOf); err != nil {
+			return err
+		}
+	}
+
+	return s.achievementRepo.SetRenewalOf(ref.ID, renewalOf)
+}
+
+// validateRenewal checks that ref may renew the certification renewedID
+func (s *CertificationService) validateRenewal(ref *model.AchievementReference, renewedID string) error {
+	if renewedID == ref.ID {
+		return errors.New("a certification cannot renew itself")
+	}
+
+	renewed, err := s.achievementRepo.FindReferenceByID(renewedID)
+	if err != nil {
+		return err
+	}
+	if renewed == nil {
+		return errors.New("renewed certification not found")
+	}
+	if renewed.StudentID != ref.StudentID {
+		return errors.New("can only renew your own certification")
+	}
+	if renewed.Status != model.StatusVerified {
+		return errors.New("only a verified certification can be renewed")
+	}
+
+	documents, err := s.achievementRepo.FindMongoByIDs([]string{ref.MongoAchievementID, renewed.MongoAchievementID}, true)
+	if err != nil {
+		return err
+	}
+	current, renewedDocument := documents[ref.MongoAchievementID], documents[renewed.MongoAchievementID]
+	if current == nil || renewedDocument == nil {
+		return errors.New("achievement not found")
+	}
+	if current.AchievementType != "certification" || renewedDocument.AchievementType != "certification" {
+		return errors.New("only certifications can be linked as renewals")
+	}
+	if current.Details.ValidUntil != nil && renewedDocument.Details.ValidUntil != nil &&
+		!current.Details.ValidUntil.After(*renewedDocument.Details.ValidUntil) {
+		return errors.New("a renewal must be valid longer than the certification it renews")
+	}
+
+	// Follow the renewed certification's own links so renewals never loop
+	for next := renewed.RenewalOf; next != nil; {
+		if *next == ref.ID {
+			return errors.New("the renewed certification is already a renewal of this one")
+		}
+		older, err := s.achievementRepo.FindReferenceByID(*next)
+		if err != nil {
+			return err
+		}
+		if older == nil {
+			break
+		}
+		next = older.RenewalOf
+	}
+
+	return nil
+}
+
+func (s *CertificationService) HandleSetRenewalOfHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+
+	var req model.SetRenewalOfRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := s.SetRenewalOf(id, userID, req.RenewalOf); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Renewal link updated", nil)
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CommentService struct {
+	commentRepo        *repository.CommentRepository
+	achievementRepo    *repository.AchievementRepository
+	revisionRepo       *repository.RevisionRepository
+	achievementService *AchievementService
+}
+
+func NewCommentService(
+	commentRepo *repository.CommentRepository,
+	achievementRepo *repository.AchievementRepository,
+	revisionRepo *repository.RevisionRepository,
+	achievementService *AchievementService,
+) *CommentService {
+	return &CommentService{
+		commentRepo:        commentRepo,
+		achievementRepo:    achievementRepo,
+		revisionRepo:       revisionRepo,
+		achievementService: achievementService,
+	}
+}
+
+// findAccessibleReference loads the achievement and checks that the user is
+// part of its thread: the owning student, the student's advisor or an admin.
+func (s *CommentService) findAccessibleReference(achievementID, userID, roleName string) (*model.AchievementReference, error) {
+	ref, err := s.achievementRepo.FindReferenceByID(achievementID)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, errors.New("achievement not found")
+	}
+
+	if err := s.achievementService.authorizeAccess(ref, userID, roleName); err != nil {
+		return nil, err
+	}
+
+	return ref, nil
+}
+
+func (s *CommentService) GetComments(achievementID, userID, roleName string) ([]*model.AchievementComment, error) {
+	ref, err := s.findAccessibleReference(achievementID, userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.commentRepo.FindByAchievementID(ref.ID)
+}
+
+func (s *CommentService) CreateComment(achievementID, userID, roleName string, req *model.CreateCommentRequest) (*model.AchievementComment, error) {
+	ref, err := s.findAccessibleReference(achievementID, userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, errors.New("comment body is required")
+	}
+
+	// Replies must stay within the same thread
+	if req.ParentID != nil {
+		parent, err := s.commentRepo.FindByID(*req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil || parent.AchievementRefID != ref.ID || parent.DeletedAt != nil {
+			return nil, errors.New("parent comment not found")
+		}
+	}
+
+	if req.Revision != nil {
+		revision, err := s.revisionRepo.FindByNumber(ref.MongoAchievementID, *req.Revision)
+		if err != nil {
+			return nil, err
+		}
+		if revision == nil {
+			return nil, errors.New("revision not found")
+		}
+	}
+
+	comment := &model.AchievementComment{
+		AchievementRefID: ref.ID,
+		ParentID:         req.ParentID,
+		AuthorID:         userID,
+		Kind:             model.CommentKindComment,
+		Body:             body,
+		Revision:         req.Revision,
+	}
+
+	if err := s.commentRepo.Create(comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// UpdateComment lets authors edit their own regular comments. Review
+// comments are part of the verification record and cannot be edited.
+func (s *CommentService) UpdateComment(achievementID, commentID, userID, roleName string, req *model.UpdateCommentRequest) error {
+	comment, err := s.findComment(achievementID, commentID, userID, roleName)
+	if err != nil {
+		return err
+	}
+
+	if comment.AuthorID != userID {
+		return errors.New("only the author can edit this comment")
+	}
+	if comment.Kind != model.CommentKindComment {
+		return errors.New("review comments cannot be edited")
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return errors.New("comment body is required")
+	}
+
+	return s.commentRepo.UpdateBody(comment.ID, body)
+}
+
+// DeleteComment soft deletes a comment. Authors may delete their own regular
+// comments, admins may delete any comment.
+func (s *CommentService) DeleteComment(achievementID, commentID, userID, roleName string) error {
+	comment, err := s.findComment(achievementID, commentID, userID, roleName)
+	if err != nil {
+		return err
+	}
+
+	if roleName != "Admin" {
+		if comment.AuthorID != userID {
+			return errors.New("only the author can delete this comment")
+		}
+		if comment.Kind != model.CommentKindComment {
+			return errors.New("review comments cannot be deleted")
+		}
+	}
+
+	return s.commentRepo.SoftDelete(comment.ID)
+}
+
+func (s *CommentService) findComment(achievementID, commentID, userID, roleName string) (*model.AchievementComment, error) {
+	ref, err := s.findAccessibleReference(achievementID, userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.commentRepo.FindByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil || comment.AchievementRefID != ref.ID || comment.DeletedAt != nil {
+		return nil, errors.New("comment not found")
+	}
+
+	return comment, nil
+}
+
+func (s *CommentService) HandleGetCommentsHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	comments, err := s.GetComments(id, userID, roleName)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Comments retrieved", comments)
+}
+
+func (s *CommentService) HandleCreateCommentHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	var req model.CreateCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	comment, err := s.CreateComment(id, userID, roleName, &req)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Comment created successfully", comment)
+}
+
+func (s *CommentService) HandleUpdateCommentHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	commentID := c.Params("commentId")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	var req model.UpdateCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := s.UpdateComment(id, commentID, userID, roleName, &req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Comment updated successfully", nil)
+}
+
+func (s *CommentService) HandleDeleteCommentHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	commentID := c.Params("commentId")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	if err := s.DeleteComment(id, commentID, userID, roleName); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Comment deleted successfully", nil)
+}
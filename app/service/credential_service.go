@@ -0,0 +1,502 @@
+package service
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var (
+	credentialContext = []interface{}{
+		"https://www.w3.org/ns/credentials/v2",
+		"https://purl.imsglobal.org/spec/ob/v3p0/context-3.0.3.json",
+	}
+	statusListContext = []interface{}{
+		"https://www.w3.org/ns/credentials/v2",
+	}
+)
+
+// openBadgeAchievementTypes maps achievement types to the Open Badges
+// achievementType vocabulary
+var openBadgeAchievementTypes = map[string]string{
+	"competition":   "Award",
+	"academic":      "Award",
+	"publication":   "Achievement",
+	"certification": "Certification",
+	"organization":  "Membership",
+}
+
+// statusListTTL is how long a served status list may be cached by verifiers
+const statusListTTL = 10 * time.Minute
+
+type CredentialService struct {
+	credentialRepo     *repository.CredentialRepository
+	achievementRepo    *repository.AchievementRepository
+	studentRepo        *repository.StudentRepository
+	achievementService *AchievementService
+	publicURL          string
+	institutionName    string
+
+	// keySecret encrypts the stored issuer key, previousKeySecret is only
+	// tried while the key is moved to a new secret
+	keySecret         string
+	previousKeySecret string
+
+	keyMu sync.Mutex
+	key   ed25519.PrivateKey
+}
+
+func NewCredentialService(
+	credentialRepo *repository.CredentialRepository,
+	achievementRepo *repository.AchievementRepository,
+	studentRepo *repository.StudentRepository,
+	achievementService *AchievementService,
+	publicURL, institutionName string,
+	keySecret, previousKeySecret string,
+) *CredentialService {
+	return &CredentialService{
+		credentialRepo:     credentialRepo,
+		achievementRepo:    achievementRepo,
+		studentRepo:        studentRepo,
+		achievementService: achievementService,
+		publicURL:          strings.TrimRight(publicURL, "/"),
+		institutionName:    institutionName,
+		keySecret:          keySecret,
+		previousKeySecret:  previousKeySecret,
+	}
+}
+
+func (s *CredentialService) issuerURL() string {
+	return s.publicURL + "/api/v1/public/issuer"
+}
+
+func (s *CredentialService) verificationMethod() string {
+	return s.issuerURL() + "#key-1"
+}
+
+func (s *CredentialService) statusListURL() string {
+	return s.publicURL + "/api/v1/public/credentials/status"
+}
+
+// signingKey loads the issuer key, generating and storing it on first use.
+// The private key is stored encrypted with keySecret.
+func (s *CredentialService) signingKey() (ed25519.PrivateKey, error) {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+
+	if s.key != nil {
+		return s.key, nil
+	}
+
+	stored, err := s.credentialRepo.FindOrCreateIssuerKey(func() (*model.IssuerKey, error) {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		sealed, err := helper.SealIssuerKey(s.keySecret, privateKey.Seed())
+		if err != nil {
+			return nil, err
+		}
+		return &model.IssuerKey{PublicKey: publicKey, PrivateKey: sealed}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := s.openIssuerKey(stored.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	if !bytes.Equal(key.Public().(ed25519.PublicKey), stored.PublicKey) {
+		return nil, errors.New("stored issuer key is invalid")
+	}
+
+	s.key = key
+	return s.key, nil
+}
+
+// PrepareSigningKey loads the issuer key at startup
+func (s *CredentialService) PrepareSigningKey() error {
+	_, err := s.signingKey()
+	return err
+}
+
+// openIssuerKey decrypts the stored seed. Seeds stored in plaintext by
+// earlier versions or sealed with previousKeySecret are sealed again with
+// keySecret, so the signing key itself never changes.
+func (s *CredentialService) openIssuerKey(stored []byte) ([]byte, error) {
+	seed, err := helper.OpenIssuerKey(s.keySecret, stored)
+	if err == nil {
+		return seed, nil
+	}
+
+	switch {
+	case len(stored) == ed25519.SeedSize:
+		seed = stored
+	case s.previousKeySecret != "":
+		if seed, err = helper.OpenIssuerKey(s.previousKeySecret, stored); err != nil {
+			return nil, errors.New("stored issuer key cannot be decrypted with the current or previous key secret")
+		}
+	default:
+		return nil, errors.New("stored issuer key cannot be decrypted, check CREDENTIAL_KEY_SECRET")
+	}
+
+	sealed, err := helper.SealIssuerKey(s.keySecret, seed)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.credentialRepo.UpdateIssuerPrivateKey(sealed); err != nil {
+		return nil, err
+	}
+	log.Println("Issuer key re-encrypted with the current key secret")
+	return seed, nil
+}
+
+// IssueCredential returns the Open Badges credential of a verified
+// achievement, signing a new one only when none is active yet
+func (s *CredentialService) IssueCredential(id, userID, roleName string) (*model.AchievementCredential, error) {
+	ref, err := s.achievementRepo.FindReferenceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, errors.New("achievement not found")
+	}
+	if err := s.achievementService.authorizeAccess(ref, userID, roleName); err != nil {
+		return nil, err
+	}
+	if ref.Status != model.StatusVerified || ref.VerifiedAt == nil {
+		return nil, errors.New("only verified achievements can be issued as a credential")
+	}
+
+	existing, err := s.credentialRepo.FindActiveByReference(ref.ID)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	key, err := s.signingKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.achievementRepo.AttachAchievements([]*model.AchievementReference{ref}, true); err != nil {
+		return nil, err
+	}
+	if ref.Achievement == nil {
+		return nil, errors.New("achievement document not found")
+	}
+	students, err := s.studentRepo.FindByIDs([]string{ref.StudentID})
+	if err != nil {
+		return nil, err
+	}
+	student, ok := students[ref.StudentID]
+	if !ok {
+		return nil, errors.New("student not found")
+	}
+
+	statusIndex, err := s.credentialRepo.NextStatusIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	credential := &model.AchievementCredential{
+		ID:               uuid.NewString(),
+		AchievementRefID: ref.ID,
+		StatusIndex:      statusIndex,
+		IssuedAt:         now,
+	}
+	credential.Credential = s.buildCredential(credential, ref, student)
+	if err := helper.AddDataIntegrityProof(credential.Credential, key, s.verificationMethod(), now); err != nil {
+		return nil, err
+	}
+
+	created, err := s.credentialRepo.Create(credential)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// Issued concurrently, hand out the credential that won
+		return s.credentialRepo.FindActiveByReference(ref.ID)
+	}
+	return credential, nil
+}
+
+func (s *CredentialService) buildCredential(credential *model.AchievementCredential, ref *model.AchievementReference, student *model.Student) map[string]interface{} {
+	achievement := ref.Achievement
+	achievementType, ok := openBadgeAchievementTypes[achievement.AchievementType]
+	if !ok {
+		achievementType = "Achievement"
+	}
+	index := strconv.Itoa(credential.StatusIndex)
+
+	document := map[string]interface{}{
+		"@context": credentialContext,
+		"id":       "urn:uuid:" + credential.ID,
+		"type":     []interface{}{"VerifiableCredential", "OpenBadgeCredential"},
+		"issuer": map[string]interface{}{
+			"id":   s.issuerURL(),
+			"type": []interface{}{"Profile"},
+			"name": s.institutionName,
+		},
+		"validFrom": ref.VerifiedAt.UTC().Format(time.RFC3339),
+		"name":      achievement.Title,
+		"credentialSubject": map[string]interface{}{
+			"type": []interface{}{"AchievementSubject"},
+			"identifier": []interface{}{
+				map[string]interface{}{
+					"type":         "IdentityObject",
+					"identityHash": student.User.FullName,
+					"identityType": "name",
+					"hashed":       false,
+				},
+			},
+			"achievement": map[string]interface{}{
+				"id":              "urn:uuid:" + ref.ID,
+				"type":            []interface{}{"Achievement"},
+				"achievementType": achievementType,
+				"name":            achievement.Title,
+				"description":     describeForSKPI(achievement).EN,
+				"criteria": map[string]interface{}{
+					"narrative": fmt.Sprintf("Verified by %s on %s", s.institutionName, ref.VerifiedAt.Format("2006-01-02")),
+				},
+			},
+		},
+		"credentialStatus": map[string]interface{}{
+			"id":                   s.statusListURL() + "#" + index,
+			"type":                 "BitstringStatusListEntry",
+			"statusPurpose":        "revocation",
+			"statusListIndex":      index,
+			"statusListCredential": s.statusListURL(),
+		},
+	}
+
+	// Certifications stop being valid when they expire
+	if achievement.Details.ValidUntil != nil {
+		document["validUntil"] = achievement.Details.ValidUntil.UTC().Format(time.RFC3339)
+	}
+	return document
+}
+
+// IssuerProfile is the issuer's Open Badges profile, which also publishes
+// the key credentials are signed with
+func (s *CredentialService) IssuerProfile() (map[string]interface{}, error) {
+	key, err := s.signingKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"@context": credentialContext,
+		"id":       s.issuerURL(),
+		"type":     []interface{}{"Profile"},
+		"name":     s.institutionName,
+		"url":      s.publicURL,
+		"verificationMethod": []interface{}{
+			map[string]interface{}{
+				"id":                 s.verificationMethod(),
+				"type":               "Multikey",
+				"controller":         s.issuerURL(),
+				"publicKeyMultibase": helper.EncodeMultikey(key.Public().(ed25519.PublicKey)),
+			},
+		},
+		"assertionMethod": []interface{}{s.verificationMethod()},
+	}, nil
+}
+
+// StatusListCredential builds the signed revocation status list. It is
+// rebuilt on each request so revocations show up immediately.
+func (s *CredentialService) StatusListCredential() (map[string]interface{}, error) {
+	key, err := s.signingKey()
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := s.encodedStatusList()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	credential := map[string]interface{}{
+		"@context":   statusListContext,
+		"id":         s.statusListURL(),
+		"type":       []interface{}{"VerifiableCredential", "BitstringStatusListCredential"},
+		"issuer":     s.issuerURL(),
+		"validFrom":  now.UTC().Format(time.RFC3339),
+		"validUntil": now.Add(statusListTTL).UTC().Format(time.RFC3339),
+		"credentialSubject": map[string]interface{}{
+			"id":            s.statusListURL() + "#list",
+			"type":          "BitstringStatusList",
+			"statusPurpose": "revocation",
+			"encodedList":   encoded,
+		},
+	}
+	if err := helper.AddDataIntegrityProof(credential, key, s.verificationMethod(), now); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+func (s *CredentialService) encodedStatusList() (string, error) {
+	indexes, err := s.credentialRepo.FindRevokedIndexes()
+	if err != nil {
+		return "", err
+	}
+	return helper.EncodeStatusList(indexes)
+}
+
+// VerifyCredential checks a presented credential without fetching anything
+// over the network: the proof against the issuer key, the issuer, the
+// validity period and the revocation status.
+func (s *CredentialService) VerifyCredential(credential map[string]interface{}) (*model.CredentialVerificationResult, error) {
+	key, err := s.signingKey()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.CredentialVerificationResult{Verified: true}
+	check := func(name string, err error) {
+		entry := model.CredentialCheck{Check: name, Passed: err == nil}
+		if err != nil {
+			entry.Error = err.Error()
+			result.Verified = false
+		}
+		result.Checks = append(result.Checks, entry)
+	}
+
+	check("proof", helper.VerifyDataIntegrityProof(credential, func(method string) (ed25519.PublicKey, error) {
+		if method != s.verificationMethod() {
+			return nil, errors.New("unknown verification method, only credentials issued by this institution can be verified")
+		}
+		return key.Public().(ed25519.PublicKey), nil
+	}))
+	check("issuer", s.checkIssuer(credential))
+	check("validity", checkValidityPeriod(credential, time.Now()))
+	check("status", s.checkStatus(credential))
+
+	return result, nil
+}
+
+func (s *CredentialService) checkIssuer(credential map[string]interface{}) error {
+	issuer := credential["issuer"]
+	if profile, ok := issuer.(map[string]interface{}); ok {
+		issuer = profile["id"]
+	}
+	if issuer != s.issuerURL() {
+		return errors.New("credential was not issued by this institution")
+	}
+	return nil
+}
+
+func checkValidityPeriod(credential map[string]interface{}, now time.Time) error {
+	if value, ok := credential["validFrom"].(string); ok {
+		validFrom, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.New("validFrom is not a valid date")
+		}
+		if now.Before(validFrom) {
+			return errors.New("credential is not valid yet")
+		}
+	}
+	if value, ok := credential["validUntil"].(string); ok {
+		validUntil, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.New("validUntil is not a valid date")
+		}
+		if now.After(validUntil) {
+			return errors.New("credential has expired")
+		}
+	}
+	return nil
+}
+
+func (s *CredentialService) checkStatus(credential map[string]interface{}) error {
+	status, ok := credential["credentialStatus"].(map[string]interface{})
+	if !ok {
+		return errors.New("credential has no status entry")
+	}
+	if status["statusListCredential"] != s.statusListURL() || status["statusPurpose"] != "revocation" {
+		return errors.New("unknown status list")
+	}
+
+	value, _ := status["statusListIndex"].(string)
+	index, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.New("invalid status list index")
+	}
+
+	encoded, err := s.encodedStatusList()
+	if err != nil {
+		return err
+	}
+	revoked, err := helper.StatusListEntrySet(encoded, index)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return errors.New("credential has been revoked")
+	}
+	return nil
+}
+
+func (s *CredentialService) HandleIssueCredentialHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	credential, err := s.IssueCredential(id, userID, roleName)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Credential issued", credential)
+}
+
+// HandleIssuerProfileHTTP serves the profile as plain JSON-LD so it can be
+// resolved from the credential's issuer id
+func (s *CredentialService) HandleIssuerProfileHTTP(c *fiber.Ctx) error {
+	profile, err := s.IssuerProfile()
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(profile, "application/ld+json")
+}
+
+func (s *CredentialService) HandleStatusListHTTP(c *fiber.Ctx) error {
+	credential, err := s.StatusListCredential()
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(statusListTTL.Seconds())))
+	return c.JSON(credential, "application/vc+ld+json")
+}
+
+func (s *CredentialService) HandleVerifyCredentialHTTP(c *fiber.Ctx) error {
+	var credential map[string]interface{}
+	if err := c.BodyParser(&credential); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	result, err := s.VerifyCredential(credential)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Credential checked", result)
+}
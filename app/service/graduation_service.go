@@ -0,0 +1,491 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GraduationService manages the SKKM graduation requirements and reports
+// each student's progress towards them from their verified achievements
+type GraduationService struct {
+	graduationRepo     *repository.GraduationRepository
+	achievementRepo    *repository.AchievementRepository
+	studentRepo        *repository.StudentRepository
+	achievementService *AchievementService
+}
+
+func NewGraduationService(
+	graduationRepo *repository.GraduationRepository,
+	achievementRepo *repository.AchievementRepository,
+	studentRepo *repository.StudentRepository,
+	achievementService *AchievementService,
+) *GraduationService {
+	return &GraduationService{
+		graduationRepo:     graduationRepo,
+		achievementRepo:    achievementRepo,
+		studentRepo:        studentRepo,
+		achievementService: achievementService,
+	}
+}
+
+// graduationCategory returns the SKKM category of an achievement type. The
+// categories are those of the SKPI; other types count as "other".
+func graduationCategory(achievementType string) string {
+	for _, category := range skpiCategories {
+		if category.key == achievementType {
+			return category.key
+		}
+	}
+	return "other"
+}
+
+func isGraduationCategory(category string) bool {
+	for _, known := range skpiCategories {
+		if known.key == category {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *GraduationService) GetRequirements() ([]*model.GraduationRequirement, error) {
+	return s.graduationRepo.FindAll()
+}
+
+func (s *GraduationService) CreateRequirement(req *model.GraduationRequirementRequest) (*model.GraduationRequirement, error) {
+	requirement, err := s.buildRequirement("", req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.graduationRepo.Create(requirement); err != nil {
+		return nil, err
+	}
+	return requirement, nil
+}
+
+func (s *GraduationService) UpdateRequirement(id string, req *model.GraduationRequirementRequest) (*model.GraduationRequirement, error) {
+	existing, err := s.graduationRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, errors.New("graduation requirement not found")
+	}
+
+	requirement, err := s.buildRequirement(existing.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	requirement.ID = existing.ID
+	requirement.CreatedAt = existing.CreatedAt
+
+	if err := s.graduationRepo.Update(requirement); err != nil {
+		return nil, err
+	}
+	return requirement, nil
+}
+
+func (s *GraduationService) DeleteRequirement(id string) error {
+	return s.graduationRepo.Delete(id)
+}
+
+// buildRequirement validates the request for the requirement with the given
+// id, empty for a new one. Each program study and cohort pair has at most
+// one requirement.
+func (s *GraduationService) buildRequirement(id string, req *model.GraduationRequirementRequest) (*model.GraduationRequirement, error) {
+	programStudy := strings.TrimSpace(req.ProgramStudy)
+	if programStudy == "" {
+		return nil, errors.New("program_study is required")
+	}
+	cohort := strings.TrimSpace(req.Cohort)
+	if req.MinPoints < 0 {
+		return nil, errors.New("min_points must not be negative")
+	}
+
+	requirement := &model.GraduationRequirement{
+		ProgramStudy: programStudy,
+		Cohort:       cohort,
+		MinPoints:    req.MinPoints,
+		Categories:   []model.RequirementCategory{},
+	}
+
+	seen := make(map[string]bool)
+	for _, category := range req.Categories {
+		if !isGraduationCategory(category.Category) {
+			return nil, fmt.Errorf("unknown category %q", category.Category)
+		}
+		if seen[category.Category] {
+			return nil, fmt.Errorf("category %q is listed more than once", category.Category)
+		}
+		if category.MinPoints <= 0 {
+			return nil, fmt.Errorf("min_points of category %q must be positive", category.Category)
+		}
+		seen[category.Category] = true
+		requirement.Categories = append(requirement.Categories, category)
+	}
+	sort.Slice(requirement.Categories, func(i, j int) bool {
+		return requirement.Categories[i].Category < requirement.Categories[j].Category
+	})
+
+	requirements, err := s.graduationRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range requirements {
+		if other.ID != id && other.ProgramStudy == programStudy && other.Cohort == cohort {
+			return nil, errors.New("a requirement for this program study and cohort already exists")
+		}
+	}
+
+	return requirement, nil
+}
+
+// GetProgress reports where the student stands. Students may only see their
+// own progress, advisors their advisees'.
+func (s *GraduationService) GetProgress(studentID, userID, roleName string) (*model.GraduationProgress, error) {
+	if err := s.achievementService.authorizeStudent(studentID, userID, roleName); err != nil {
+		return nil, err
+	}
+
+	progress, err := s.progressFor([]string{studentID})
+	if err != nil {
+		return nil, err
+	}
+	if len(progress) == 0 {
+		return nil, errors.New("student not found")
+	}
+	return progress[0], nil
+}
+
+// GetOwnProgress reports the progress of the student signed in as userID
+func (s *GraduationService) GetOwnProgress(userID string) (*model.GraduationProgress, error) {
+	student, err := s.studentRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if student == nil {
+		return nil, errors.New("student profile not found")
+	}
+
+	progress, err := s.progressFor([]string{student.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(progress) == 0 {
+		return nil, errors.New("student not found")
+	}
+	return progress[0], nil
+}
+
+// GetAtRisk lists the students visible to the user who have a requirement
+// they do not meet yet, furthest behind first. Admins and Kaprodi see every
+// student, advisors only their advisees. Empty filters are not applied.
+func (s *GraduationService) GetAtRisk(userID, roleName, programStudy, cohort string) ([]*model.GraduationProgress, error) {
+	studentIDs, scoped, err := s.achievementService.scopeStudentIDs(userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if !scoped {
+		studentIDs, err = s.studentRepo.FindIDs(programStudy, cohort)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	progress, err := s.progressFor(studentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	atRisk := []*model.GraduationProgress{}
+	for _, student := range progress {
+		if student.Requirement == nil || student.Met {
+			continue
+		}
+		if programStudy != "" && student.ProgramStudy != programStudy {
+			continue
+		}
+		if cohort != "" && student.Cohort != cohort {
+			continue
+		}
+		atRisk = append(atRisk, student)
+	}
+
+	sort.Slice(atRisk, func(i, j int) bool {
+		a, b := completion(atRisk[i]), completion(atRisk[j])
+		if a != b {
+			return a < b
+		}
+		return atRisk[i].StudentNumber < atRisk[j].StudentNumber
+	})
+	return atRisk, nil
+}
+
+// completion is the share of the overall minimum a student has reached
+func completion(progress *model.GraduationProgress) float64 {
+	if progress.Requirement.MinPoints == 0 {
+		return 1
+	}
+	return progress.TotalPoints / float64(progress.Requirement.MinPoints)
+}
+
+// progressFor computes the progress of the students, in the order of
+// studentIDs. Unknown ids are skipped. Points follow the leaderboard: team
+// achievements count for every member who did not decline, shared according
+// to their team point rule, and duplicates are left out. Expired
+// certifications still count since their points were earned.
+func (s *GraduationService) progressFor(studentIDs []string) ([]*model.GraduationProgress, error) {
+	students, err := s.studentRepo.FindByIDs(studentIDs)
+	if err != nil {
+		return nil, err
+	}
+	requirements, err := s.graduationRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.achievementRepo.FindCreditedForStudents(studentIDs)
+	if err != nil {
+		return nil, err
+	}
+	mongoIDs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		mongoIDs = append(mongoIDs, row.MongoAchievementID)
+	}
+	documents, err := s.achievementRepo.FindMongoByIDs(mongoIDs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	byStudent := make(map[string]*model.GraduationProgress, len(students))
+	categoryPoints := make(map[string]map[string]float64, len(students))
+	result := make([]*model.GraduationProgress, 0, len(students))
+	for _, id := range studentIDs {
+		student, ok := students[id]
+		if !ok || byStudent[id] != nil {
+			continue
+		}
+		progress := &model.GraduationProgress{
+			StudentID:     student.ID,
+			StudentNumber: student.StudentID,
+			FullName:      student.User.FullName,
+			ProgramStudy:  student.ProgramStudy,
+			Cohort:        student.AcademicYear,
+			Requirement:   requirementFor(requirements, student.ProgramStudy, student.AcademicYear),
+		}
+		byStudent[id] = progress
+		categoryPoints[id] = make(map[string]float64)
+		result = append(result, progress)
+	}
+
+	for _, row := range rows {
+		progress, ok := byStudent[row.StudentID]
+		if !ok {
+			continue
+		}
+		achievement, ok := documents[row.MongoAchievementID]
+		if !ok {
+			continue
+		}
+		points := model.MemberPoints(achievement.Points, row.TeamPointRule, row.TeamSize)
+		progress.TotalPoints += points
+		progress.AchievementCount++
+		categoryPoints[row.StudentID][graduationCategory(achievement.AchievementType)] += points
+	}
+
+	for _, progress := range result {
+		evaluateProgress(progress, categoryPoints[progress.StudentID])
+	}
+	return result, nil
+}
+
+// requirementFor picks the requirement of the cohort, falling back to the
+// program study default
+func requirementFor(requirements []*model.GraduationRequirement, programStudy, cohort string) *model.GraduationRequirement {
+	var fallback *model.GraduationRequirement
+	for _, requirement := range requirements {
+		if requirement.ProgramStudy != programStudy {
+			continue
+		}
+		if requirement.Cohort == cohort {
+			return requirement
+		}
+		if requirement.Cohort == "" {
+			fallback = requirement
+		}
+	}
+	return fallback
+}
+
+// evaluateProgress fills in the categories and whether the requirement is
+// met. Categories are listed in SKPI order, those without points or a
+// minimum are left out.
+func evaluateProgress(progress *model.GraduationProgress, points map[string]float64) {
+	minimums := make(map[string]int)
+	if progress.Requirement != nil {
+		for _, category := range progress.Requirement.Categories {
+			minimums[category.Category] = category.MinPoints
+		}
+	}
+
+	progress.Met = progress.Requirement != nil
+	progress.Categories = []model.CategoryProgress{}
+	for _, known := range skpiCategories {
+		minimum, required := minimums[known.key]
+		if !required && points[known.key] == 0 {
+			continue
+		}
+		category := model.CategoryProgress{
+			Category:  known.key,
+			Points:    points[known.key],
+			MinPoints: minimum,
+			Remaining: max(float64(minimum)-points[known.key], 0),
+		}
+		category.Met = category.Remaining == 0
+		if !category.Met {
+			progress.Met = false
+		}
+		progress.Categories = append(progress.Categories, category)
+	}
+
+	if progress.Requirement != nil {
+		progress.Remaining = max(float64(progress.Requirement.MinPoints)-progress.TotalPoints, 0)
+		if progress.Remaining > 0 {
+			progress.Met = false
+		}
+	}
+}
+
+var atRiskExportColumns = []model.ExportColumn{
+	{Header: "NIM", Type: model.ExportColumnText},
+	{Header: "Nama", Type: model.ExportColumnText},
+	{Header: "Program Studi", Type: model.ExportColumnText},
+	{Header: "Angkatan", Type: model.ExportColumnText},
+	{Header: "Total Poin", Type: model.ExportColumnNumber},
+	{Header: "Minimum Poin", Type: model.ExportColumnNumber},
+	{Header: "Kekurangan", Type: model.ExportColumnNumber},
+	{Header: "Kategori Belum Terpenuhi", Type: model.ExportColumnText},
+}
+
+func atRiskExportTable(students []*model.GraduationProgress) helper.ExportTable {
+	return helper.ExportTable{
+		Title:    "Mahasiswa Belum Memenuhi SKKM",
+		Subtitle: fmt.Sprintf("%d mahasiswa", len(students)),
+		Columns:  atRiskExportColumns,
+		Rows: func(emit helper.ExportRowFunc) error {
+			for _, student := range students {
+				var unmet []string
+				for _, category := range student.Categories {
+					if !category.Met {
+						unmet = append(unmet, category.Category)
+					}
+				}
+				err := emit([]interface{}{
+					student.StudentNumber, student.FullName, student.ProgramStudy, student.Cohort,
+					student.TotalPoints, student.Requirement.MinPoints, student.Remaining, strings.Join(unmet, ", "),
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func (s *GraduationService) HandleGetRequirementsHTTP(c *fiber.Ctx) error {
+	requirements, err := s.GetRequirements()
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Graduation requirements retrieved", requirements)
+}
+
+func (s *GraduationService) HandleCreateRequirementHTTP(c *fiber.Ctx) error {
+	var req model.GraduationRequirementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	requirement, err := s.CreateRequirement(&req)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Graduation requirement created successfully", requirement)
+}
+
+func (s *GraduationService) HandleUpdateRequirementHTTP(c *fiber.Ctx) error {
+	var req model.GraduationRequirementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	requirement, err := s.UpdateRequirement(c.Params("id"), &req)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Graduation requirement updated successfully", requirement)
+}
+
+func (s *GraduationService) HandleDeleteRequirementHTTP(c *fiber.Ctx) error {
+	if err := s.DeleteRequirement(c.Params("id")); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Graduation requirement deleted successfully", nil)
+}
+
+func (s *GraduationService) HandleGetOwnProgressHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	progress, err := s.GetOwnProgress(userID)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Graduation progress retrieved", progress)
+}
+
+func (s *GraduationService) HandleGetProgressHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	progress, err := s.GetProgress(c.Params("studentId"), userID, roleName)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Graduation progress retrieved", progress)
+}
+
+func (s *GraduationService) HandleGetAtRiskHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	format, err := helper.ParseExportFormat(c)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	students, err := s.GetAtRisk(userID, roleName, c.Query("program_study"), c.Query("cohort"))
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if format != "" {
+		return s.achievementService.sendExport(c, format, "mahasiswa-belum-memenuhi-skkm", atRiskExportTable(students))
+	}
+
+	return helper.SuccessResponse(c, "At-risk students retrieved", students)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// Notify stores an in-app notification for a user
+func (s *NotificationService) Notify(userID, notificationType, title, message string, achievementRefID *string) error {
+	return s.notificationRepo.Create(&model.Notification{
+		UserID:           userID,
+		Type:             notificationType,
+		Title:            title,
+		Message:          message,
+		AchievementRefID: achievementRefID,
+	})
+}
+
+func (s *NotificationService) GetNotifications(userID string, unreadOnly bool, page, limit int) ([]*model.Notification, *model.Pagination, error) {
+	offset := (page - 1) * limit
+	notifications, total, err := s.notificationRepo.GetByUser(userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPages := int(total) / limit
+	if int(total)%limit != 0 {
+		totalPages++
+	}
+
+	pagination := &model.Pagination{
+		Page:       page,
+		Limit:      limit,
+		TotalItems: total,
+		TotalPages: totalPages,
+	}
+
+	return notifications, pagination, nil
+}
+
+func (s *NotificationService) HandleGetNotificationsHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	unreadOnly := c.Query("unread", "false") == "true"
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	notifications, pagination, err := s.GetNotifications(userID, unreadOnly, page, limit)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.PaginatedResponse(c, notifications, *pagination)
+}
+
+func (s *NotificationService) HandleMarkReadHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+
+	if err := s.notificationRepo.MarkRead(id, userID); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Notification marked as read", nil)
+}
+
+func (s *NotificationService) HandleMarkAllReadHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	if err := s.notificationRepo.MarkAllRead(userID); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "All notifications marked as read", nil)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"time"
+)
+
+const (
+	// outboxBatchSize is how many due events the relay handles per run
+	outboxBatchSize = 100
+
+	outboxRetryBase = 30 * time.Second
+	outboxRetryMax  = time.Hour
+)
+
+// OutboxService applies the MongoDB side of achievement changes recorded in
+// the outbox. Events are applied right after the PostgreSQL transaction
+// commits; failures are retried by a background relay with backoff. A create
+// that keeps failing is compensated by removing the reference while it is
+// still a draft, anything else that keeps failing is marked failed for
+// reconciliation.
+type OutboxService struct {
+	outboxRepo      *repository.OutboxRepository
+	achievementRepo *repository.AchievementRepository
+	revisionRepo    *repository.RevisionRepository
+	maxAttempts     int
+}
+
+func NewOutboxService(
+	outboxRepo *repository.OutboxRepository,
+	achievementRepo *repository.AchievementRepository,
+	revisionRepo *repository.RevisionRepository,
+	maxAttempts int,
+) *OutboxService {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &OutboxService{
+		outboxRepo:      outboxRepo,
+		achievementRepo: achievementRepo,
+		revisionRepo:    revisionRepo,
+		maxAttempts:     maxAttempts,
+	}
+}
+
+// Start runs the relay every interval and returns a function that stops it
+func (s *OutboxService) Start(interval time.Duration) func() {
+	return StartScheduler("outbox-relay", interval, s.RunOnce)
+}
+
+// Dispatch applies a freshly committed event. On failure the event stays
+// pending for the relay and the error is returned for logging.
+func (s *OutboxService) Dispatch(event *model.OutboxEvent) error {
+	return s.process(event)
+}
+
+// CreatePending reports whether the document of the reference has not been
+// written to MongoDB yet
+func (s *OutboxService) CreatePending(achievementRefID string) (bool, error) {
+	return s.outboxRepo.HasPendingCreate(achievementRefID)
+}
+
+// RunOnce applies the pending events that are due
+func (s *OutboxService) RunOnce() error {
+	events, err := s.outboxRepo.FindDue(outboxBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err := s.process(event); err != nil {
+			log.Printf("Outbox event %s (%s) failed: %v", event.ID, event.Operation, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *OutboxService) process(event *model.OutboxEvent) error {
+	applyErr := s.apply(event)
+	if applyErr == nil {
+		_, err := s.outboxRepo.MarkProcessed(event.ID, model.OutboxStatusDone, nil)
+		return err
+	}
+
+	attempts := event.Attempts + 1
+	if attempts >= s.maxAttempts {
+		if err := s.giveUp(event, applyErr); err != nil {
+			return err
+		}
+		return applyErr
+	}
+
+	if err := s.outboxRepo.ScheduleRetry(event.ID, applyErr.Error(), time.Now().Add(outboxBackoff(attempts))); err != nil {
+		return err
+	}
+	return applyErr
+}
+
+// apply performs the MongoDB change. Both operations are idempotent so an
+// event may safely be applied more than once. A delete removes the revisions
+// before the document, so a failed attempt never leaves history behind
+// without its document.
+func (s *OutboxService) apply(event *model.OutboxEvent) error {
+	switch event.Operation {
+	case model.OutboxCreateDocument:
+		var achievement model.Achievement
+		if err := json.Unmarshal(event.Payload, &achievement); err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+		return s.achievementRepo.InsertMongoIfAbsent(&achievement)
+	case model.OutboxDeleteDocument:
+		if err := s.revisionRepo.DeleteByAchievementID(event.MongoAchievementID); err != nil {
+			return err
+		}
+		return s.achievementRepo.DeleteMongo(event.MongoAchievementID)
+	}
+	return errors.New("unknown outbox operation")
+}
+
+// giveUp closes an event that ran out of attempts
+func (s *OutboxService) giveUp(event *model.OutboxEvent, cause error) error {
+	message := cause.Error()
+
+	if event.Operation == model.OutboxCreateDocument {
+		// The document never made it to MongoDB, drop the reference as well.
+		// A reference that left draft is kept for reconciliation instead.
+		deleted, err := s.achievementRepo.DeleteDraftReference(event.AchievementRefID)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			log.Printf("Outbox event %s compensated: achievement %s removed", event.ID, event.AchievementRefID)
+			_, err := s.outboxRepo.MarkProcessed(event.ID, model.OutboxStatusCompensated, &message)
+			return err
+		}
+	}
+
+	log.Printf("Outbox event %s gave up after %d attempts, document %s needs reconciliation", event.ID, s.maxAttempts, event.MongoAchievementID)
+	_, err := s.outboxRepo.MarkProcessed(event.ID, model.OutboxStatusFailed, &message)
+	return err
+}
+
+// outboxBackoff doubles the delay with every attempt, up to outboxRetryMax
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxRetryBase
+	for i := 1; i < attempts && delay < outboxRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > outboxRetryMax {
+		delay = outboxRetryMax
+	}
+	return delay
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// periodCodePattern keeps period codes usable as query values, such as
+// 2025-1 for Ganjil 2025/2026
+var periodCodePattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,20}$`)
+
+// periodReassignBatch is how many documents are read per query when
+// achievements are reassigned to periods
+const periodReassignBatch = 500
+
+// PeriodService manages academic periods and keeps the period assigned to
+// each achievement in line with them
+type PeriodService struct {
+	periodRepo         *repository.PeriodRepository
+	achievementRepo    *repository.AchievementRepository
+	achievementService *AchievementService
+
+	// reassignMu runs one reassignment at a time, so the last one to run
+	// always sees the latest periods
+	reassignMu sync.Mutex
+}
+
+func NewPeriodService(
+	periodRepo *repository.PeriodRepository,
+	achievementRepo *repository.AchievementRepository,
+	achievementService *AchievementService,
+) *PeriodService {
+	return &PeriodService{
+		periodRepo:         periodRepo,
+		achievementRepo:    achievementRepo,
+		achievementService: achievementService,
+	}
+}
+
+func (s *PeriodService) GetPeriods() ([]*model.AcademicPeriod, error) {
+	return s.periodRepo.FindAll()
+}
+
+func (s *PeriodService) GetActivePeriod() (*model.AcademicPeriod, error) {
+	period, err := s.periodRepo.FindActive()
+	if err != nil {
+		return nil, err
+	}
+	if period == nil {
+		return nil, errors.New("no academic period is active")
+	}
+	return period, nil
+}
+
+func (s *PeriodService) CreatePeriod(req *model.AcademicPeriodRequest) (*model.AcademicPeriod, error) {
+	period, err := s.buildPeriod("", req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.periodRepo.Create(period); err != nil {
+		return nil, err
+	}
+	s.reassignInBackground()
+	return period, nil
+}
+
+func (s *PeriodService) UpdatePeriod(id string, req *model.AcademicPeriodRequest) (*model.AcademicPeriod, error) {
+	existing, err := s.periodRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, errors.New("academic period not found")
+	}
+
+	period, err := s.buildPeriod(existing.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	period.ID = existing.ID
+	period.CreatedAt = existing.CreatedAt
+
+	if err := s.periodRepo.Update(period); err != nil {
+		return nil, err
+	}
+	s.reassignInBackground()
+	return period, nil
+}
+
+// DeletePeriod removes the period. Its achievements are left without one,
+// since periods never overlap no other period covers them.
+func (s *PeriodService) DeletePeriod(id string) error {
+	if err := s.periodRepo.Delete(id); err != nil {
+		return err
+	}
+	s.achievementService.leaderboards.invalidate()
+	return nil
+}
+
+// buildPeriod validates the request for the period with the given id, empty
+// for a new period. The code must be unique and the date range must not
+// overlap another period.
+func (s *PeriodService) buildPeriod(id string, req *model.AcademicPeriodRequest) (*model.AcademicPeriod, error) {
+	code := strings.TrimSpace(req.Code)
+	if !periodCodePattern.MatchString(code) || code == activePeriod {
+		return nil, errors.New("code must be up to 20 letters, digits or dashes, such as 2025-1")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if len(name) > 100 {
+		return nil, errors.New("name must be at most 100 characters")
+	}
+
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, errors.New("start_date must be a date in YYYY-MM-DD format")
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return nil, errors.New("end_date must be a date in YYYY-MM-DD format")
+	}
+	if end.Before(start) {
+		return nil, errors.New("end_date must not be before start_date")
+	}
+
+	other, err := s.periodRepo.FindByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if other != nil && other.ID != id {
+		return nil, fmt.Errorf("code %q is already used", code)
+	}
+
+	overlapping, err := s.periodRepo.FindOverlapping(start, end, id)
+	if err != nil {
+		return nil, err
+	}
+	if overlapping != nil {
+		return nil, fmt.Errorf("dates overlap academic period %s (%s to %s)", overlapping.Code,
+			overlapping.StartDate.Format("2006-01-02"), overlapping.EndDate.Format("2006-01-02"))
+	}
+
+	return &model.AcademicPeriod{
+		Code:      code,
+		Name:      name,
+		StartDate: start,
+		EndDate:   end,
+		IsActive:  req.IsActive,
+	}, nil
+}
+
+// reassignInBackground reassigns the achievements after a period was saved.
+// It reads every achievement, so the admin's request does not wait for it;
+// failures are logged and the next period change runs it again.
+func (s *PeriodService) reassignInBackground() {
+	go func() {
+		if err := s.ReassignAchievements(); err != nil {
+			log.Printf("Academic periods: reassigning achievements failed: %v", err)
+		}
+	}()
+}
+
+// ReassignAchievements places every achievement in the period its event
+// date, or creation time when it has none, falls in. It runs after periods
+// change so reports filtered by period stay accurate.
+func (s *PeriodService) ReassignAchievements() error {
+	s.reassignMu.Lock()
+	defer s.reassignMu.Unlock()
+
+	periods, err := s.periodRepo.FindAll()
+	if err != nil {
+		return err
+	}
+	keys, err := s.achievementRepo.ListReferencePeriodKeys()
+	if err != nil {
+		return err
+	}
+
+	// References to move, grouped by their new period ("" for none)
+	moves := make(map[string][]string)
+	moved := 0
+	for start := 0; start < len(keys); start += periodReassignBatch {
+		batch := keys[start:min(start+periodReassignBatch, len(keys))]
+
+		mongoIDs := make([]string, 0, len(batch))
+		for _, key := range batch {
+			mongoIDs = append(mongoIDs, key.MongoAchievementID)
+		}
+		documents, err := s.achievementRepo.FindMongoByIDs(mongoIDs, true)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range batch {
+			date := key.CreatedAt
+			if document, ok := documents[key.MongoAchievementID]; ok && document.Details.EventDate != nil {
+				date = *document.Details.EventDate
+			}
+
+			periodID := periodForDate(periods, date)
+			if equalStringPointers(periodID, key.PeriodID) {
+				continue
+			}
+			target := ""
+			if periodID != nil {
+				target = *periodID
+			}
+			moves[target] = append(moves[target], key.ID)
+			moved++
+		}
+	}
+
+	for target, ids := range moves {
+		var periodID *string
+		if target != "" {
+			periodID = &target
+		}
+		if err := s.achievementRepo.AssignPeriod(periodID, ids); err != nil {
+			return err
+		}
+	}
+
+	if moved > 0 {
+		log.Printf("Academic periods: reassigned %d achievements", moved)
+		s.achievementService.leaderboards.invalidate()
+	}
+	return nil
+}
+
+// periodForDate returns the id of the period whose range includes the
+// calendar date, or nil
+func periodForDate(periods []*model.AcademicPeriod, date time.Time) *string {
+	day := date.Format("2006-01-02")
+	for _, period := range periods {
+		if day >= period.StartDate.Format("2006-01-02") && day <= period.EndDate.Format("2006-01-02") {
+			return &period.ID
+		}
+	}
+	return nil
+}
+
+func (s *PeriodService) HandleGetPeriodsHTTP(c *fiber.Ctx) error {
+	periods, err := s.GetPeriods()
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Academic periods retrieved", periods)
+}
+
+func (s *PeriodService) HandleGetActivePeriodHTTP(c *fiber.Ctx) error {
+	period, err := s.GetActivePeriod()
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Active academic period retrieved", period)
+}
+
+func (s *PeriodService) HandleCreatePeriodHTTP(c *fiber.Ctx) error {
+	var req model.AcademicPeriodRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	period, err := s.CreatePeriod(&req)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Academic period created successfully", period)
+}
+
+func (s *PeriodService) HandleUpdatePeriodHTTP(c *fiber.Ctx) error {
+	var req model.AcademicPeriodRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	period, err := s.UpdatePeriod(c.Params("id"), &req)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Academic period updated successfully", period)
+}
+
+func (s *PeriodService) HandleDeletePeriodHTTP(c *fiber.Ctx) error {
+	if err := s.DeletePeriod(c.Params("id")); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Academic period deleted successfully", nil)
+}
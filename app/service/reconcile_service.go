@@ -0,0 +1,228 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ReconcileService finds and repairs inconsistencies between achievement
+// references in PostgreSQL and achievement documents in MongoDB. PostgreSQL
+// is treated as the source of truth for ownership.
+type ReconcileService struct {
+	achievementRepo *repository.AchievementRepository
+	studentRepo     *repository.StudentRepository
+	revisionRepo    *repository.RevisionRepository
+	outboxRepo      *repository.OutboxRepository
+}
+
+func NewReconcileService(
+	achievementRepo *repository.AchievementRepository,
+	studentRepo *repository.StudentRepository,
+	revisionRepo *repository.RevisionRepository,
+	outboxRepo *repository.OutboxRepository,
+) *ReconcileService {
+	return &ReconcileService{
+		achievementRepo: achievementRepo,
+		studentRepo:     studentRepo,
+		revisionRepo:    revisionRepo,
+		outboxRepo:      outboxRepo,
+	}
+}
+
+// Reconcile scans both stores and plans a repair for every inconsistency.
+// Repairs are only carried out when apply is set.
+func (s *ReconcileService) Reconcile(apply bool) (*model.ReconcileReport, error) {
+	report, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	report.DryRun = !apply
+	if apply {
+		for _, issue := range report.Issues {
+			if issue.Repair == model.RepairNone {
+				continue
+			}
+			if err := s.repair(issue); err != nil {
+				issue.Error = err.Error()
+			} else {
+				issue.Applied = true
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (s *ReconcileService) scan() (*model.ReconcileReport, error) {
+	refs, err := s.achievementRepo.ListReferenceKeys()
+	if err != nil {
+		return nil, err
+	}
+	docs, err := s.achievementRepo.ListMongoKeys()
+	if err != nil {
+		return nil, err
+	}
+	// Changes still travelling through the outbox are not inconsistencies
+	openOperations, err := s.outboxRepo.OpenOperations()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.ReconcileReport{
+		ScannedReferences: len(refs),
+		ScannedDocuments:  len(docs),
+		Issues:            []*model.ReconcileIssue{},
+	}
+
+	docsByID := make(map[string]model.DocumentKey, len(docs))
+	for _, doc := range docs {
+		docsByID[doc.ID] = doc
+	}
+
+	referenced := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		ref := ref
+		referenced[ref.MongoAchievementID] = true
+
+		doc, ok := docsByID[ref.MongoAchievementID]
+		if !ok {
+			if openOperations[ref.MongoAchievementID] == model.OutboxCreateDocument {
+				continue
+			}
+			issue, err := s.planDanglingReference(ref)
+			if err != nil {
+				return nil, err
+			}
+			report.Issues = append(report.Issues, issue)
+			continue
+		}
+
+		if doc.StudentID != ref.StudentID {
+			docStudentID := doc.StudentID
+			report.Issues = append(report.Issues, &model.ReconcileIssue{
+				Kind:               model.IssueStudentMismatch,
+				AchievementRefID:   &ref.ID,
+				MongoAchievementID: ref.MongoAchievementID,
+				StudentID:          &ref.StudentID,
+				DocumentStudentID:  &docStudentID,
+				Repair:             model.RepairFixStudentID,
+			})
+		}
+	}
+
+	studentExists := make(map[string]bool)
+	for _, doc := range docs {
+		if referenced[doc.ID] {
+			continue
+		}
+
+		docStudentID := doc.StudentID
+		issue := &model.ReconcileIssue{
+			Kind:               model.IssueOrphanDocument,
+			MongoAchievementID: doc.ID,
+			DocumentStudentID:  &docStudentID,
+		}
+
+		exists, checked := studentExists[doc.StudentID]
+		if !checked {
+			// Malformed ids cannot belong to a student
+			if uuidPattern.MatchString(doc.StudentID) {
+				student, err := s.studentRepo.FindByID(doc.StudentID)
+				if err != nil {
+					return nil, err
+				}
+				exists = student != nil
+			}
+			studentExists[doc.StudentID] = exists
+		}
+
+		switch {
+		case openOperations[doc.ID] == model.OutboxDeleteDocument:
+			issue.Repair = model.RepairArchiveDocument
+			issue.Detail = "deletion did not reach MongoDB"
+		case exists:
+			issue.Repair = model.RepairRecreateReference
+			issue.StudentID = &docStudentID
+		default:
+			issue.Repair = model.RepairArchiveDocument
+			issue.Detail = "owning student no longer exists"
+		}
+
+		report.Issues = append(report.Issues, issue)
+	}
+
+	return report, nil
+}
+
+// planDanglingReference restores a missing document from its latest revision
+// when one exists; otherwise the reference is left for manual review
+func (s *ReconcileService) planDanglingReference(ref model.ReferenceKey) (*model.ReconcileIssue, error) {
+	issue := &model.ReconcileIssue{
+		Kind:               model.IssueDanglingReference,
+		AchievementRefID:   &ref.ID,
+		MongoAchievementID: ref.MongoAchievementID,
+		StudentID:          &ref.StudentID,
+		Repair:             model.RepairNone,
+		Detail:             "no revision to restore from",
+	}
+
+	revision, err := s.revisionRepo.FindLatest(ref.MongoAchievementID)
+	if err != nil {
+		return nil, err
+	}
+	if revision != nil {
+		issue.Repair = model.RepairRestoreDocument
+		issue.Detail = fmt.Sprintf("from revision %d", revision.Revision)
+	}
+
+	return issue, nil
+}
+
+func (s *ReconcileService) repair(issue *model.ReconcileIssue) error {
+	switch issue.Repair {
+	case model.RepairFixStudentID:
+		return s.achievementRepo.SetMongoStudentID(issue.MongoAchievementID, *issue.StudentID)
+
+	case model.RepairRecreateReference:
+		ref := &model.AchievementReference{
+			StudentID:          *issue.StudentID,
+			MongoAchievementID: issue.MongoAchievementID,
+			Status:             model.StatusDraft,
+		}
+		if err := s.achievementRepo.CreateReference(ref); err != nil {
+			return err
+		}
+		issue.AchievementRefID = &ref.ID
+		return nil
+
+	case model.RepairArchiveDocument:
+		return s.achievementRepo.ArchiveMongo(issue.MongoAchievementID, issue.Detail)
+
+	case model.RepairRestoreDocument:
+		revision, err := s.revisionRepo.FindLatest(issue.MongoAchievementID)
+		if err != nil {
+			return err
+		}
+		if revision == nil {
+			return errors.New("revision no longer exists")
+		}
+		objectID, err := primitive.ObjectIDFromHex(issue.MongoAchievementID)
+		if err != nil {
+			return err
+		}
+		snapshot := revision.Snapshot
+		snapshot.ID = objectID
+		snapshot.StudentID = *issue.StudentID
+		return s.achievementRepo.InsertMongoIfAbsent(&snapshot)
+	}
+
+	return fmt.Errorf("unknown repair %q", issue.Repair)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// purgeBatchSize bounds how many achievements one purge run removes
+const purgeBatchSize = 100
+
+// RetentionService keeps soft deleted achievements restorable for the
+// retention window and afterwards purges them from PostgreSQL and MongoDB.
+type RetentionService struct {
+	achievementRepo *repository.AchievementRepository
+	studentRepo     *repository.StudentRepository
+	outboxService   *OutboxService
+	retention       time.Duration
+}
+
+func NewRetentionService(
+	achievementRepo *repository.AchievementRepository,
+	studentRepo *repository.StudentRepository,
+	outboxService *OutboxService,
+	retention time.Duration,
+) *RetentionService {
+	return &RetentionService{
+		achievementRepo: achievementRepo,
+		studentRepo:     studentRepo,
+		outboxService:   outboxService,
+		retention:       retention,
+	}
+}
+
+// Start runs the purge on the given interval until stopped
+func (s *RetentionService) Start(interval time.Duration) func() {
+	return StartScheduler("achievement-purge", interval, func() error {
+		_, err := s.Purge()
+		return err
+	})
+}
+
+// ListDeleted lists the soft deleted achievements a student may restore, or
+// those of every student for an admin
+func (s *RetentionService) ListDeleted(userID, roleName string) ([]*model.DeletedAchievement, error) {
+	studentID := ""
+	if roleName != "Admin" {
+		student, err := s.studentRepo.FindByUserID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if student == nil {
+			return nil, errors.New("student profile not found")
+		}
+		studentID = student.ID
+	}
+
+	refs, err := s.achievementRepo.ListDeletedReferences(studentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.achievementRepo.AttachAchievements(refs, true); err != nil {
+		return nil, err
+	}
+
+	deleted := make([]*model.DeletedAchievement, 0, len(refs))
+	for _, ref := range refs {
+		deleted = append(deleted, &model.DeletedAchievement{
+			AchievementReference: ref,
+			PurgeAt:              ref.DeletedAt.Add(s.retention),
+		})
+	}
+	return deleted, nil
+}
+
+// Restore brings back a soft deleted achievement within the retention
+// window. Only its owner or an admin may restore it.
+func (s *RetentionService) Restore(id, userID, roleName string) error {
+	ref, err := s.achievementRepo.FindDeletedReferenceByID(id)
+	if err != nil {
+		return err
+	}
+	if ref == nil {
+		return errors.New("deleted achievement not found")
+	}
+
+	if roleName != "Admin" {
+		student, err := s.studentRepo.FindByUserID(userID)
+		if err != nil {
+			return err
+		}
+		if student == nil || student.ID != ref.StudentID {
+			return errors.New("unauthorized")
+		}
+	}
+
+	if time.Since(*ref.DeletedAt) >= s.retention {
+		return errors.New("retention window has passed, the achievement is scheduled for purging")
+	}
+
+	return s.achievementRepo.RestoreReference(ref.ID)
+}
+
+// Purge permanently removes the achievements soft deleted longer than the
+// retention window. Each reference is deleted together with an outbox event
+// for its document, the relay retries documents that cannot be removed
+// right away.
+func (s *RetentionService) Purge() (*model.PurgeResult, error) {
+	result := &model.PurgeResult{}
+	deletedBefore := time.Now().Add(-s.retention)
+
+	for {
+		refs, err := s.achievementRepo.FindPurgeCandidates(deletedBefore, purgeBatchSize)
+		if err != nil {
+			return result, err
+		}
+
+		for _, ref := range refs {
+			event := &model.OutboxEvent{
+				MongoAchievementID: ref.MongoAchievementID,
+				Operation:          model.OutboxDeleteDocument,
+			}
+			purged, err := s.achievementRepo.PurgeReferenceWithOutbox(ref, event, deletedBefore)
+			if err != nil {
+				return result, err
+			}
+			if !purged {
+				continue
+			}
+			result.Purged++
+
+			if err := s.outboxService.Dispatch(event); err != nil {
+				log.Printf("Achievement %s: document deletion deferred: %v", ref.ID, err)
+			}
+		}
+
+		if len(refs) < purgeBatchSize {
+			break
+		}
+	}
+
+	if result.Purged > 0 {
+		log.Printf("Purged %d soft deleted achievement(s)", result.Purged)
+	}
+	return result, nil
+}
+
+func (s *RetentionService) HandleListDeletedHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	deleted, err := s.ListDeleted(userID, roleName)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Deleted achievements retrieved", deleted)
+}
+
+func (s *RetentionService) HandleRestoreHTTP(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	if err := s.Restore(id, userID, roleName); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Achievement restored successfully", nil)
+}
+
+func (s *RetentionService) HandlePurgeHTTP(c *fiber.Ctx) error {
+	result, err := s.Purge()
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Purge completed", result)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"log"
+	"time"
+)
+
+// StartScheduler runs job every interval in the background until the
+// returned stop function is called. Errors are logged and the job is retried
+// on the next tick. A non-positive interval disables the job.
+func StartScheduler(name string, interval time.Duration, job func() error) func() {
+	if interval <= 0 {
+		log.Printf("Scheduler %s disabled", name)
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := job(); err != nil {
+					log.Printf("Scheduler %s failed: %v", name, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	log.Printf("Scheduler %s started (every %s)", name, interval)
+	return func() { close(done) }
+}
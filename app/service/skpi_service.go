@@ -0,0 +1,367 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SKPIService struct {
+	skpiRepo           *repository.SKPIRepository
+	achievementRepo    *repository.AchievementRepository
+	studentRepo        *repository.StudentRepository
+	achievementService *AchievementService
+	institutionName    string
+}
+
+func NewSKPIService(
+	skpiRepo *repository.SKPIRepository,
+	achievementRepo *repository.AchievementRepository,
+	studentRepo *repository.StudentRepository,
+	achievementService *AchievementService,
+	institutionName string,
+) *SKPIService {
+	return &SKPIService{
+		skpiRepo:           skpiRepo,
+		achievementRepo:    achievementRepo,
+		studentRepo:        studentRepo,
+		achievementService: achievementService,
+		institutionName:    institutionName,
+	}
+}
+
+// skpiCategories lists the SKPI categories in the order they are printed.
+// Achievement types without a category of their own are listed as "other".
+var skpiCategories = []struct {
+	key  string
+	name model.SKPIText
+}{
+	{"competition", model.SKPIText{ID: "Kompetisi", EN: "Competitions"}},
+	{"academic", model.SKPIText{ID: "Prestasi Akademik", EN: "Academic Achievements"}},
+	{"publication", model.SKPIText{ID: "Publikasi Ilmiah", EN: "Scientific Publications"}},
+	{"certification", model.SKPIText{ID: "Sertifikasi", EN: "Certifications"}},
+	{"organization", model.SKPIText{ID: "Pengalaman Organisasi", EN: "Organizational Experience"}},
+	{"other", model.SKPIText{ID: "Prestasi Lainnya", EN: "Other Achievements"}},
+}
+
+var skpiLevels = map[string]model.SKPIText{
+	"international": {ID: "Internasional", EN: "International"},
+	"national":      {ID: "Nasional", EN: "National"},
+	"regional":      {ID: "Regional", EN: "Regional"},
+	"provincial":    {ID: "Provinsi", EN: "Provincial"},
+	"local":         {ID: "Lokal", EN: "Local"},
+	"university":    {ID: "Universitas", EN: "University"},
+}
+
+var skpiMedals = map[string]model.SKPIText{
+	"gold":   {ID: "Emas", EN: "Gold"},
+	"silver": {ID: "Perak", EN: "Silver"},
+	"bronze": {ID: "Perunggu", EN: "Bronze"},
+}
+
+var skpiPublicationTypes = map[string]model.SKPIText{
+	"journal":    {ID: "Jurnal", EN: "Journal"},
+	"conference": {ID: "Konferensi", EN: "Conference"},
+	"book":       {ID: "Buku", EN: "Book"},
+}
+
+// bilingual looks a value up in a bilingual vocabulary, falling back to the
+// value itself in both languages
+func bilingual(vocabulary map[string]model.SKPIText, value string) model.SKPIText {
+	if text, ok := vocabulary[strings.ToLower(value)]; ok {
+		return text
+	}
+	return model.SKPIText{ID: value, EN: value}
+}
+
+// GetSKPI returns the student's SKPI achievements section: the frozen
+// snapshot once finalized, otherwise a draft built from the current
+// verified achievements. Students may only read their own, advisors their
+// advisees'.
+func (s *SKPIService) GetSKPI(studentID, userID, roleName string) (*model.SKPIDocument, error) {
+	if err := s.authorize(studentID, userID, roleName); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.skpiRepo.FindSnapshot(studentID)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		return &snapshot.Document, nil
+	}
+
+	return s.buildDocument(studentID)
+}
+
+// FinalizeSKPI freezes the current document. Achievements verified or
+// revised afterwards no longer change it.
+func (s *SKPIService) FinalizeSKPI(studentID, userID string) (*model.SKPIDocument, error) {
+	document, err := s.buildDocument(studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	document.Finalized = true
+	document.FinalizedAt = &now
+	document.FinalizedBy = &userID
+
+	snapshot := &model.SKPISnapshot{
+		StudentID:   studentID,
+		Document:    *document,
+		FinalizedBy: userID,
+		FinalizedAt: now,
+	}
+	if err := s.skpiRepo.CreateSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+func (s *SKPIService) authorize(studentID, userID, roleName string) error {
+	return s.achievementService.authorizeStudent(studentID, userID, roleName)
+}
+
+func (s *SKPIService) buildDocument(studentID string) (*model.SKPIDocument, error) {
+	students, err := s.studentRepo.FindByIDs([]string{studentID})
+	if err != nil {
+		return nil, err
+	}
+	student, ok := students[studentID]
+	if !ok {
+		return nil, errors.New("student not found")
+	}
+
+	refs, err := s.achievementRepo.FindVerifiedForStudent(studentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.achievementRepo.AttachAchievements(refs, false); err != nil {
+		return nil, err
+	}
+
+	items := make(map[string][]model.SKPIItem)
+	for _, ref := range refs {
+		if ref.Achievement == nil {
+			continue
+		}
+		key := skpiCategoryKey(ref.Achievement.AchievementType)
+		items[key] = append(items[key], model.SKPIItem{
+			AchievementID: ref.ID,
+			Title:         ref.Achievement.Title,
+			Description:   describeForSKPI(ref.Achievement),
+			EventDate:     ref.Achievement.Details.EventDate,
+			VerifiedAt:    ref.VerifiedAt,
+		})
+	}
+
+	document := &model.SKPIDocument{
+		Institution: s.institutionName,
+		Student: model.SKPIStudent{
+			ID:            student.ID,
+			StudentNumber: student.StudentID,
+			FullName:      student.User.FullName,
+			ProgramStudy:  student.ProgramStudy,
+			AcademicYear:  student.AcademicYear,
+		},
+		Categories:  []model.SKPICategory{},
+		GeneratedAt: time.Now(),
+	}
+
+	for _, category := range skpiCategories {
+		categoryItems := items[category.key]
+		if len(categoryItems) == 0 {
+			continue
+		}
+		sort.SliceStable(categoryItems, func(i, j int) bool {
+			return skpiDate(categoryItems[i]).Before(skpiDate(categoryItems[j]))
+		})
+		document.Categories = append(document.Categories, model.SKPICategory{
+			Key:   category.key,
+			Name:  category.name,
+			Items: categoryItems,
+		})
+	}
+
+	return document, nil
+}
+
+func skpiCategoryKey(achievementType string) string {
+	for _, category := range skpiCategories {
+		if category.key == achievementType {
+			return achievementType
+		}
+	}
+	return "other"
+}
+
+// skpiDate orders items within a category by when they took place
+func skpiDate(item model.SKPIItem) time.Time {
+	if item.EventDate != nil {
+		return *item.EventDate
+	}
+	if item.VerifiedAt != nil {
+		return *item.VerifiedAt
+	}
+	return time.Time{}
+}
+
+// describeForSKPI writes the achievement as a sentence in both languages
+// from its type specific details. Free text such as names and titles is
+// kept as entered.
+func describeForSKPI(achievement *model.Achievement) model.SKPIText {
+	details := achievement.Details
+	var id, en []string
+
+	switch achievement.AchievementType {
+	case "competition":
+		name := firstNonEmpty(details.CompetitionName, achievement.Title)
+		switch {
+		case details.Rank > 0:
+			id = append(id, fmt.Sprintf("Juara %d %s", details.Rank, name))
+			en = append(en, fmt.Sprintf("%s place, %s", ordinal(details.Rank), name))
+		case details.MedalType != "":
+			medal := bilingual(skpiMedals, details.MedalType)
+			id = append(id, fmt.Sprintf("Medali %s %s", medal.ID, name))
+			en = append(en, fmt.Sprintf("%s medal, %s", medal.EN, name))
+		default:
+			id = append(id, "Peserta "+name)
+			en = append(en, "Participant, "+name)
+		}
+		if details.CompetitionLevel != "" {
+			level := bilingual(skpiLevels, details.CompetitionLevel)
+			id = append(id, "tingkat "+level.ID)
+			en = append(en, level.EN+" level")
+		}
+
+	case "publication":
+		title := firstNonEmpty(details.PublicationTitle, achievement.Title)
+		if details.PublicationType != "" {
+			kind := bilingual(skpiPublicationTypes, details.PublicationType)
+			id = append(id, fmt.Sprintf("Publikasi %s \"%s\"", strings.ToLower(kind.ID), title))
+			en = append(en, fmt.Sprintf("%s publication \"%s\"", kind.EN, title))
+		} else {
+			id = append(id, fmt.Sprintf("Publikasi \"%s\"", title))
+			en = append(en, fmt.Sprintf("Publication \"%s\"", title))
+		}
+		if details.Publisher != "" {
+			id = append(id, "diterbitkan oleh "+details.Publisher)
+			en = append(en, "published by "+details.Publisher)
+		}
+
+	case "organization":
+		organization := firstNonEmpty(details.OrganizationName, achievement.Title)
+		if details.Position != "" {
+			id = append(id, fmt.Sprintf("%s di %s", details.Position, organization))
+			en = append(en, fmt.Sprintf("%s at %s", details.Position, organization))
+		} else {
+			id = append(id, "Anggota "+organization)
+			en = append(en, "Member of "+organization)
+		}
+		if details.Period != nil {
+			span := fmt.Sprintf("%s - %s", details.Period.Start.Format("01/2006"), details.Period.End.Format("01/2006"))
+			id = append(id, span)
+			en = append(en, span)
+		}
+
+	case "certification":
+		name := firstNonEmpty(details.CertificationName, achievement.Title)
+		id = append(id, "Sertifikat "+name)
+		en = append(en, name+" certificate")
+		if details.IssuedBy != "" {
+			id = append(id, "diterbitkan oleh "+details.IssuedBy)
+			en = append(en, "issued by "+details.IssuedBy)
+		}
+
+	default:
+		id = append(id, achievement.Title)
+		en = append(en, achievement.Title)
+		if details.Organizer != "" {
+			id = append(id, "diselenggarakan oleh "+details.Organizer)
+			en = append(en, "organized by "+details.Organizer)
+		}
+	}
+
+	if details.EventDate != nil {
+		year := details.EventDate.Format("2006")
+		id = append(id, year)
+		en = append(en, year)
+	}
+
+	return model.SKPIText{ID: strings.Join(id, ", "), EN: strings.Join(en, ", ")}
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func (s *SKPIService) HandleGetSKPIHTTP(c *fiber.Ctx) error {
+	studentID := c.Params("studentId")
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	format := c.Query("format", "json")
+	if format != "json" && format != model.ExportFormatPDF {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "format must be json or pdf")
+	}
+
+	document, err := s.GetSKPI(studentID, userID, roleName)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+
+	if format == model.ExportFormatPDF {
+		var buffer bytes.Buffer
+		if err := helper.WriteSKPIPDF(&buffer, document); err != nil {
+			return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		}
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="skpi-%s.pdf"`, document.Student.StudentNumber))
+		return c.Send(buffer.Bytes())
+	}
+
+	return helper.SuccessResponse(c, "SKPI retrieved", document)
+}
+
+func (s *SKPIService) HandleFinalizeSKPIHTTP(c *fiber.Ctx) error {
+	studentID := c.Params("studentId")
+	userID := c.Locals("userID").(string)
+
+	document, err := s.FinalizeSKPI(studentID, userID)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "SKPI finalized", document)
+}
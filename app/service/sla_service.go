@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SLAService watches submissions waiting for review. After ReminderAfter the
+// approver of the pending step is reminded, after EscalateAfter every user
+// with EscalationRole is notified.
+type SLAService struct {
+	slaRepo             *repository.SLARepository
+	userRepo            *repository.UserRepository
+	lecturerRepo        *repository.LecturerRepository
+	notificationService *NotificationService
+	reminderAfter       time.Duration
+	escalateAfter       time.Duration
+	escalationRole      string
+}
+
+func NewSLAService(
+	slaRepo *repository.SLARepository,
+	userRepo *repository.UserRepository,
+	lecturerRepo *repository.LecturerRepository,
+	notificationService *NotificationService,
+	reminderAfter, escalateAfter time.Duration,
+	escalationRole string,
+) *SLAService {
+	return &SLAService{
+		slaRepo:             slaRepo,
+		userRepo:            userRepo,
+		lecturerRepo:        lecturerRepo,
+		notificationService: notificationService,
+		reminderAfter:       reminderAfter,
+		escalateAfter:       escalateAfter,
+		escalationRole:      escalationRole,
+	}
+}
+
+// Start runs the SLA check on the given interval until stopped
+func (s *SLAService) Start(interval time.Duration) func() {
+	return StartScheduler("verification-sla", interval, s.RunOnce)
+}
+
+// RunOnce sends due reminders and escalations
+func (s *SLAService) RunOnce() error {
+	now := time.Now()
+
+	reminders, err := s.slaRepo.FindReminderCandidates(now.Add(-s.reminderAfter))
+	if err != nil {
+		return err
+	}
+	usersByRole := make(map[string][]string)
+	for _, sub := range reminders {
+		recipients, err := s.reminderRecipients(sub, usersByRole)
+		if err != nil {
+			return err
+		}
+		if len(recipients) == 0 {
+			continue
+		}
+		message := fmt.Sprintf("Prestasi %s (%s) menunggu verifikasi sejak %s.",
+			sub.StudentName, sub.StudentNumber, sub.SubmittedAt.Format("2006-01-02 15:04"))
+		for _, userID := range recipients {
+			if err := s.notificationService.Notify(userID, model.NotificationReviewReminder,
+				"Pengingat verifikasi prestasi", message, &sub.AchievementRefID); err != nil {
+				return err
+			}
+		}
+		if err := s.slaRepo.MarkReminderSent(sub.AchievementRefID); err != nil {
+			return err
+		}
+	}
+
+	escalations, err := s.slaRepo.FindEscalationCandidates(now.Add(-s.escalateAfter))
+	if err != nil {
+		return err
+	}
+	if len(escalations) == 0 {
+		return nil
+	}
+
+	recipients, err := s.userRepo.GetUserIDsByRole(s.escalationRole)
+	if err != nil {
+		return err
+	}
+	for _, sub := range escalations {
+		message := fmt.Sprintf("Prestasi %s (%s) belum diverifikasi sejak %s dan dieskalasi.",
+			sub.StudentName, sub.StudentNumber, sub.SubmittedAt.Format("2006-01-02 15:04"))
+		for _, userID := range recipients {
+			if err := s.notificationService.Notify(userID, model.NotificationReviewEscalation,
+				"Eskalasi verifikasi prestasi", message, &sub.AchievementRefID); err != nil {
+				return err
+			}
+		}
+		if err := s.slaRepo.MarkEscalated(sub.AchievementRefID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reminderRecipients returns who may decide the pending approval step: the
+// student's advisor when the step allows Dosen Wali, otherwise every user
+// holding one of the step's roles. Submissions made before approval chains
+// were stored on the reference go to the advisor. usersByRole caches role
+// lookups within one run.
+func (s *SLAService) reminderRecipients(sub *model.StaleSubmission, usersByRole map[string][]string) ([]string, error) {
+	if sub.ApprovalStep >= len(sub.ApprovalSteps) {
+		if sub.AdvisorUserID == nil {
+			return nil, nil
+		}
+		return []string{*sub.AdvisorUserID}, nil
+	}
+
+	step := sub.ApprovalSteps[sub.ApprovalStep]
+	for _, role := range step.AllowedRoles {
+		if role == "Dosen Wali" && sub.AdvisorUserID != nil {
+			return []string{*sub.AdvisorUserID}, nil
+		}
+	}
+
+	var recipients []string
+	for _, role := range step.AllowedRoles {
+		if role == "Dosen Wali" {
+			continue
+		}
+		userIDs, ok := usersByRole[role]
+		if !ok {
+			var err error
+			userIDs, err = s.userRepo.GetUserIDsByRole(role)
+			if err != nil {
+				return nil, err
+			}
+			usersByRole[role] = userIDs
+		}
+		recipients = append(recipients, userIDs...)
+	}
+	return recipients, nil
+}
+
+// GetReviewLatency returns the per-advisor latency report. Advisors only see
+// their own row.
+func (s *SLAService) GetReviewLatency(userID, roleName string) ([]*model.AdvisorReviewLatency, error) {
+	lecturerID := ""
+	if roleName == "Dosen Wali" {
+		lecturer, err := s.lecturerRepo.FindByUserID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if lecturer == nil {
+			return nil, errors.New("lecturer profile not found")
+		}
+		lecturerID = lecturer.ID
+	}
+
+	return s.slaRepo.GetReviewLatency(time.Now().Add(-s.reminderAfter), lecturerID)
+}
+
+func (s *SLAService) HandleReviewLatencyHTTP(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	roleName := c.Locals("roleName").(string)
+
+	report, err := s.GetReviewLatency(userID, roleName)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Review latency retrieved", report)
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/helper"
+	"sort"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxTagLength matches the tags.name and tag_synonyms.synonym columns
+const maxTagLength = 100
+
+// TagService manages the tag vocabulary and maps the tags entered on
+// achievements to their canonical names
+type TagService struct {
+	tagRepo         *repository.TagRepository
+	achievementRepo *repository.AchievementRepository
+	revisionRepo    *repository.RevisionRepository
+}
+
+func NewTagService(
+	tagRepo *repository.TagRepository,
+	achievementRepo *repository.AchievementRepository,
+	revisionRepo *repository.RevisionRepository,
+) *TagService {
+	return &TagService{
+		tagRepo:         tagRepo,
+		achievementRepo: achievementRepo,
+		revisionRepo:    revisionRepo,
+	}
+}
+
+func (s *TagService) GetTags() ([]*model.Tag, error) {
+	return s.tagRepo.FindAll()
+}
+
+func (s *TagService) GetTag(id string) (*model.Tag, error) {
+	tag, err := s.tagRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		return nil, errors.New("tag not found")
+	}
+	return tag, nil
+}
+
+func (s *TagService) CreateTag(req *model.TagRequest) (*model.Tag, error) {
+	tag, err := s.buildTag("", req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tagRepo.Create(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *TagService) UpdateTag(id string, req *model.TagRequest) (*model.Tag, error) {
+	existing, err := s.GetTag(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := s.buildTag(existing.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	tag.ID = existing.ID
+	tag.CreatedAt = existing.CreatedAt
+
+	if err := s.tagRepo.Update(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *TagService) DeleteTag(id string) error {
+	return s.tagRepo.Delete(id)
+}
+
+// buildTag validates the request for the tag with the given id, empty for a
+// new tag. The name and synonyms must not be used by another tag and the
+// parent must not be the tag itself or one of its descendants.
+func (s *TagService) buildTag(id string, req *model.TagRequest) (*model.Tag, error) {
+	name := helper.CleanTag(req.Name)
+	if name == "" {
+		return nil, errors.New("tag name is required")
+	}
+	if len(name) > maxTagLength {
+		return nil, fmt.Errorf("tag name must be at most %d characters", maxTagLength)
+	}
+
+	tag := &model.Tag{Name: name, ParentID: req.ParentID, Description: req.Description, Synonyms: []string{}}
+	if tag.ParentID != nil && *tag.ParentID == "" {
+		tag.ParentID = nil
+	}
+
+	keys := []string{helper.TagKey(name)}
+	seen := map[string]bool{keys[0]: true}
+	for _, synonym := range req.Synonyms {
+		synonym = helper.CleanTag(synonym)
+		key := helper.TagKey(synonym)
+		if synonym == "" || seen[key] {
+			continue
+		}
+		if len(synonym) > maxTagLength {
+			return nil, fmt.Errorf("synonym %q must be at most %d characters", synonym, maxTagLength)
+		}
+		seen[key] = true
+		keys = append(keys, key)
+		tag.Synonyms = append(tag.Synonyms, synonym)
+	}
+
+	used, err := s.tagRepo.FindByKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if other, ok := used[key]; ok && other.ID != id {
+			return nil, fmt.Errorf("%q is already used by tag %q", key, other.Name)
+		}
+	}
+
+	for parentID := tag.ParentID; parentID != nil; {
+		if *parentID == id {
+			return nil, errors.New("a tag cannot be placed under itself or one of its descendants")
+		}
+		parent, err := s.tagRepo.FindByID(*parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, errors.New("parent tag not found")
+		}
+		parentID = parent.ParentID
+	}
+
+	return tag, nil
+}
+
+// Autocomplete suggests vocabulary tags matching what the user typed so far
+func (s *TagService) Autocomplete(query string, limit int) ([]*model.TagSuggestion, error) {
+	key := helper.TagKey(query)
+	if key == "" {
+		return []*model.TagSuggestion{}, nil
+	}
+	return s.tagRepo.Autocomplete(key, limit)
+}
+
+// NormalizeTags replaces tags found in the vocabulary, by name or synonym,
+// with their canonical name. Tags outside the vocabulary are kept as typed.
+// Duplicates are dropped, keeping the first occurrence.
+func (s *TagService) NormalizeTags(tags []string) ([]string, error) {
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, helper.TagKey(tag))
+	}
+
+	matches, err := s.tagRepo.FindByKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	vocabulary := make(map[string]string, len(matches))
+	for key, tag := range matches {
+		vocabulary[key] = tag.Name
+	}
+	normalized, _ := normalizeTagList(tags, vocabulary)
+	return normalized, nil
+}
+
+// normalizeTagList maps tags to their canonical names using vocabulary,
+// keyed by helper.TagKey. It also returns the tags that are not in the
+// vocabulary.
+func normalizeTagList(tags []string, vocabulary map[string]string) (normalized, unknown []string) {
+	normalized = []string{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = helper.CleanTag(tag)
+		if tag == "" {
+			continue
+		}
+
+		if name, ok := vocabulary[helper.TagKey(tag)]; ok {
+			tag = name
+		} else {
+			unknown = append(unknown, tag)
+		}
+
+		key := helper.TagKey(tag)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, unknown
+}
+
+// MigrateTags rewrites the tags of existing achievements to their canonical
+// names, merging spellings and synonyms of the same tag. Without apply only
+// the planned changes are reported; applied changes are recorded as a
+// revision of the achievement. Tags outside the vocabulary are counted so
+// they can be curated.
+func (s *TagService) MigrateTags(apply bool) (*model.TagMigrationReport, error) {
+	tags, err := s.tagRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	vocabulary := make(map[string]string)
+	for _, tag := range tags {
+		vocabulary[helper.TagKey(tag.Name)] = tag.Name
+		for _, synonym := range tag.Synonyms {
+			vocabulary[helper.TagKey(synonym)] = tag.Name
+		}
+	}
+
+	documents, err := s.achievementRepo.ListMongoTags()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.TagMigrationReport{
+		DryRun:           !apply,
+		ScannedDocuments: len(documents),
+		Changes:          []*model.TagChange{},
+		UnknownTags:      []model.TagUsage{},
+	}
+	unknownCounts := make(map[string]*model.TagUsage)
+
+	for _, document := range documents {
+		normalized, unknown := normalizeTagList(document.Tags, vocabulary)
+
+		counted := make(map[string]bool)
+		for _, tag := range unknown {
+			key := helper.TagKey(tag)
+			if counted[key] {
+				continue
+			}
+			counted[key] = true
+			if usage, ok := unknownCounts[key]; ok {
+				usage.Count++
+			} else {
+				unknownCounts[key] = &model.TagUsage{Tag: tag, Count: 1}
+			}
+		}
+
+		if equalTags(document.Tags, normalized) {
+			continue
+		}
+
+		report.Changes = append(report.Changes, &model.TagChange{
+			MongoAchievementID: document.ID,
+			Before:             document.Tags,
+			After:              normalized,
+		})
+	}
+
+	if apply {
+		if err := s.applyTagChanges(report.Changes); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, usage := range unknownCounts {
+		report.UnknownTags = append(report.UnknownTags, *usage)
+	}
+	sort.Slice(report.UnknownTags, func(i, j int) bool {
+		if report.UnknownTags[i].Count != report.UnknownTags[j].Count {
+			return report.UnknownTags[i].Count > report.UnknownTags[j].Count
+		}
+		return report.UnknownTags[i].Tag < report.UnknownTags[j].Tag
+	})
+
+	return report, nil
+}
+
+// applyTagChanges writes the planned changes. A change that fails is
+// reported on the change itself so the others still go through.
+func (s *TagService) applyTagChanges(changes []*model.TagChange) error {
+	mongoIDs := make([]string, 0, len(changes))
+	for _, change := range changes {
+		mongoIDs = append(mongoIDs, change.MongoAchievementID)
+	}
+	refs, err := s.achievementRepo.FindReferencesByMongoIDs(mongoIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		status := ""
+		if ref, ok := refs[change.MongoAchievementID]; ok {
+			status = ref.Status
+		}
+		if err := s.applyTagChange(change, status); err != nil {
+			change.Error = err.Error()
+		} else {
+			change.Applied = true
+		}
+	}
+	return nil
+}
+
+// applyTagChange rewrites the tags of one document and records the result as
+// a revision. Documents without history get their current state as the
+// baseline first. When the revision cannot be recorded the tags are rolled
+// back, so the history never misses a change.
+func (s *TagService) applyTagChange(change *model.TagChange, status string) error {
+	previous, err := s.achievementRepo.FindMongoByID(change.MongoAchievementID)
+	if err != nil {
+		return err
+	}
+
+	latest, err := s.revisionRepo.LatestNumber(change.MongoAchievementID)
+	if err != nil {
+		return err
+	}
+	if latest == 0 {
+		if err := s.recordRevision(previous, status, "baseline"); err != nil {
+			return err
+		}
+	}
+
+	if err := s.achievementRepo.SetMongoTags(change.MongoAchievementID, change.After); err != nil {
+		return err
+	}
+
+	updated, err := s.achievementRepo.FindMongoByID(change.MongoAchievementID)
+	if err == nil {
+		err = s.recordRevision(updated, status, "tag migration")
+	}
+	if err != nil {
+		if rollbackErr := s.achievementRepo.SetMongoTags(change.MongoAchievementID, previous.Tags); rollbackErr != nil {
+			log.Printf("Achievement %s: tag migration not rolled back: %v", change.MongoAchievementID, rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// recordRevision stores a revision made by the migration itself, which has
+// no author
+func (s *TagService) recordRevision(achievement *model.Achievement, status, note string) error {
+	return s.revisionRepo.Create(&model.AchievementRevision{
+		AchievementID: achievement.ID.Hex(),
+		Status:        status,
+		Note:          note,
+		Snapshot:      *achievement,
+	})
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *TagService) HandleGetTagsHTTP(c *fiber.Ctx) error {
+	tags, err := s.GetTags()
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Tags retrieved", tags)
+}
+
+func (s *TagService) HandleGetTagHTTP(c *fiber.Ctx) error {
+	tag, err := s.GetTag(c.Params("id"))
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Tag retrieved", tag)
+}
+
+func (s *TagService) HandleAutocompleteHTTP(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	suggestions, err := s.Autocomplete(c.Query("q"), limit)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Tag suggestions retrieved", suggestions)
+}
+
+func (s *TagService) HandleCreateTagHTTP(c *fiber.Ctx) error {
+	var req model.TagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	tag, err := s.CreateTag(&req)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Tag created successfully", tag)
+}
+
+func (s *TagService) HandleUpdateTagHTTP(c *fiber.Ctx) error {
+	var req model.TagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	tag, err := s.UpdateTag(c.Params("id"), &req)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Tag updated successfully", tag)
+}
+
+func (s *TagService) HandleDeleteTagHTTP(c *fiber.Ctx) error {
+	if err := s.DeleteTag(c.Params("id")); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Tag deleted successfully", nil)
+}
@@ -13,22 +13,12 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
+// stopSchedulers stops the background jobs started by SetupApp
+var stopSchedulers []func()
+
 // SetupApp creates and configures the Fiber application
 func SetupApp(cfg *Config) (*fiber.App, error) {
-	// Connect to databases
-	if err := database.ConnectPostgres(
-		cfg.Postgres.Host,
-		cfg.Postgres.Port,
-		cfg.Postgres.User,
-		cfg.Postgres.Password,
-		cfg.Postgres.Database,
-	); err != nil {
-		LogError("Failed to connect to PostgreSQL: %v", err)
-		return nil, err
-	}
-
-	if err := database.ConnectMongoDB(cfg.MongoDB.URI, cfg.MongoDB.Database); err != nil {
-		LogError("Failed to connect to MongoDB: %v", err)
+	if err := ConnectDatabases(cfg); err != nil {
 		return nil, err
 	}
 
@@ -37,8 +27,55 @@ func SetupApp(cfg *Config) (*fiber.App, error) {
 	studentRepo := repository.NewStudentRepository()
 	lecturerRepo := repository.NewLecturerRepository()
 	achievementRepo := repository.NewAchievementRepository()
+	revisionRepo := repository.NewRevisionRepository()
+	commentRepo := repository.NewCommentRepository()
+	approvalRepo := repository.NewApprovalRepository()
+	memberRepo := repository.NewMemberRepository()
+	duplicateRepo := repository.NewDuplicateRepository()
+	notificationRepo := repository.NewNotificationRepository()
+	slaRepo := repository.NewSLARepository()
+	outboxRepo := repository.NewOutboxRepository()
+	skpiRepo := repository.NewSKPIRepository()
+	credentialRepo := repository.NewCredentialRepository()
+	tagRepo := repository.NewTagRepository()
+	periodRepo := repository.NewPeriodRepository()
+	graduationRepo := repository.NewGraduationRepository()
 
 	authService := service.NewAuthService(userRepo, cfg.JWT.Secret, cfg.JWT.Expiration, cfg.JWT.RefreshExpiration)
+	notificationService := service.NewNotificationService(notificationRepo)
+	outboxService := service.NewOutboxService(outboxRepo, achievementRepo, revisionRepo, cfg.Outbox.MaxAttempts)
+	tagService := service.NewTagService(tagRepo, achievementRepo, revisionRepo)
+	achievementService := service.NewAchievementService(
+		achievementRepo, studentRepo, lecturerRepo, revisionRepo, commentRepo, approvalRepo,
+		memberRepo, duplicateRepo, outboxService, notificationService, tagService, periodRepo,
+		cfg.Report.InstitutionName,
+	)
+	skpiService := service.NewSKPIService(skpiRepo, achievementRepo, studentRepo, achievementService, cfg.Report.InstitutionName)
+	certificateService := service.NewCertificateService(
+		achievementRepo, studentRepo, userRepo, achievementService,
+		cfg.Cert.Secret, cfg.Cert.PublicURL, cfg.Report.InstitutionName,
+	)
+	credentialService := service.NewCredentialService(
+		credentialRepo, achievementRepo, studentRepo, achievementService,
+		cfg.Cert.PublicURL, cfg.Report.InstitutionName,
+		cfg.Cert.KeySecret, cfg.Cert.PreviousKeySecret,
+	)
+	// Fails early on a wrong key secret and encrypts keys stored in plaintext
+	if err := credentialService.PrepareSigningKey(); err != nil {
+		return nil, err
+	}
+	commentService := service.NewCommentService(commentRepo, achievementRepo, revisionRepo, achievementService)
+	approvalService := service.NewApprovalService(approvalRepo, userRepo)
+	retentionService := service.NewRetentionService(achievementRepo, studentRepo, outboxService, cfg.Retention.Window)
+	periodService := service.NewPeriodService(periodRepo, achievementRepo, achievementService)
+	graduationService := service.NewGraduationService(graduationRepo, achievementRepo, studentRepo, achievementService)
+	certificationService := service.NewCertificationService(
+		achievementRepo, studentRepo, notificationService, achievementService, cfg.Expiry.NoticeBefore,
+	)
+	slaService := service.NewSLAService(
+		slaRepo, userRepo, lecturerRepo, notificationService,
+		cfg.SLA.ReminderAfter, cfg.SLA.EscalateAfter, cfg.SLA.EscalationRole,
+	)
 
 	// Create Fiber app
 	fiberApp := fiber.New(fiber.Config{
@@ -59,12 +96,43 @@ func SetupApp(cfg *Config) (*fiber.App, error) {
 	RegisterMiddleware(fiberApp)
 
 	// Register routes
-	route.Setup(fiberApp, cfg.JWT.Secret, authService, userRepo, achievementRepo, studentRepo, lecturerRepo)
+	route.Setup(
+		fiberApp, cfg.JWT.Secret, authService, userRepo, achievementService, commentService,
+		approvalService, notificationService, slaService, skpiService, certificateService, credentialService,
+		retentionService, certificationService, tagService, periodService, graduationService, studentRepo, lecturerRepo,
+	)
+
+	// Start background jobs
+	stopSchedulers = append(stopSchedulers, slaService.Start(cfg.SLA.CheckInterval))
+	stopSchedulers = append(stopSchedulers, outboxService.Start(cfg.Outbox.RelayInterval))
+	stopSchedulers = append(stopSchedulers, retentionService.Start(cfg.Retention.PurgeInterval))
+	stopSchedulers = append(stopSchedulers, certificationService.Start(cfg.Expiry.CheckInterval))
 
 	LogInfo("Application setup completed successfully")
 	return fiberApp, nil
 }
 
+// ConnectDatabases connects to PostgreSQL and MongoDB
+func ConnectDatabases(cfg *Config) error {
+	if err := database.ConnectPostgres(
+		cfg.Postgres.Host,
+		cfg.Postgres.Port,
+		cfg.Postgres.User,
+		cfg.Postgres.Password,
+		cfg.Postgres.Database,
+	); err != nil {
+		LogError("Failed to connect to PostgreSQL: %v", err)
+		return err
+	}
+
+	if err := database.ConnectMongoDB(cfg.MongoDB.URI, cfg.MongoDB.Database); err != nil {
+		LogError("Failed to connect to MongoDB: %v", err)
+		return err
+	}
+
+	return nil
+}
+
 // RegisterMiddleware registers all middleware for the Fiber app
 func RegisterMiddleware(fiberApp *fiber.App) {
 	// CORS middleware
@@ -117,8 +185,13 @@ func RegisterHealthChecks(fiberApp *fiber.App) {
 	LogInfo("Health check routes registered")
 }
 
-// CloseConnections closes all database connections
+// CloseConnections stops background jobs and closes all database connections
 func CloseConnections() {
+	for _, stop := range stopSchedulers {
+		stop()
+	}
+	stopSchedulers = nil
+
 	database.ClosePostgres()
 	database.CloseMongoDB()
 	LogInfo("Database connections closed")
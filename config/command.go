@@ -0,0 +1,204 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"projek_uas/app/model"
+	"projek_uas/app/repository"
+	"projek_uas/app/service"
+)
+
+// RunCommand runs a maintenance subcommand instead of the API server and
+// returns the process exit code
+func RunCommand(args []string) int {
+	switch args[0] {
+	case "reconcile":
+		return runReconcile(args[1:])
+	case "migrate-tags":
+		return runMigrateTags(args[1:])
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+	fmt.Fprintln(os.Stderr, "available commands: reconcile, migrate-tags")
+	return 2
+}
+
+// runReconcile reports inconsistencies between the PostgreSQL references and
+// the MongoDB documents, and repairs them with -apply
+func runReconcile(args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: table or json")
+	apply := flags.Bool("apply", false, "apply the repairs (default is a dry run)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintln(os.Stderr, "format must be table or json")
+		return 2
+	}
+
+	cfg := Load()
+	if err := ConnectDatabases(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		return 1
+	}
+	defer CloseConnections()
+
+	reconcileService := service.NewReconcileService(
+		repository.NewAchievementRepository(), repository.NewStudentRepository(),
+		repository.NewRevisionRepository(), repository.NewOutboxRepository(),
+	)
+
+	report, err := reconcileService.Reconcile(*apply)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reconcile failed: %v\n", err)
+		return 1
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return 1
+		}
+	} else {
+		printReconcileTable(os.Stdout, report)
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Error != "" {
+			return 1
+		}
+	}
+	return 0
+}
+
+func printReconcileTable(out io.Writer, report *model.ReconcileReport) {
+	mode := "apply"
+	if report.DryRun {
+		mode = "dry run"
+	}
+	fmt.Fprintf(out, "Scanned %d references and %d documents (%s), %d issue(s)\n\n",
+		report.ScannedReferences, report.ScannedDocuments, mode, len(report.Issues))
+	if len(report.Issues) == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tREFERENCE\tDOCUMENT\tSTUDENT\tDOCUMENT STUDENT\tREPAIR\tRESULT")
+	for _, issue := range report.Issues {
+		result := "planned"
+		if issue.Repair == model.RepairNone {
+			result = "manual"
+		} else if issue.Error != "" {
+			result = "error: " + issue.Error
+		} else if issue.Applied {
+			result = "applied"
+		}
+		if issue.Detail != "" {
+			result += " (" + issue.Detail + ")"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			issue.Kind, valueOrDash(issue.AchievementRefID), issue.MongoAchievementID,
+			valueOrDash(issue.StudentID), valueOrDash(issue.DocumentStudentID), issue.Repair, result)
+	}
+	w.Flush()
+}
+
+// runMigrateTags rewrites the tags of existing achievements to their
+// canonical vocabulary names, and applies the changes with -apply
+func runMigrateTags(args []string) int {
+	flags := flag.NewFlagSet("migrate-tags", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: table or json")
+	apply := flags.Bool("apply", false, "apply the changes (default is a dry run)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintln(os.Stderr, "format must be table or json")
+		return 2
+	}
+
+	cfg := Load()
+	if err := ConnectDatabases(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		return 1
+	}
+	defer CloseConnections()
+
+	tagService := service.NewTagService(
+		repository.NewTagRepository(), repository.NewAchievementRepository(), repository.NewRevisionRepository(),
+	)
+
+	report, err := tagService.MigrateTags(*apply)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-tags failed: %v\n", err)
+		return 1
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return 1
+		}
+	} else {
+		printTagMigrationTable(os.Stdout, report)
+	}
+
+	for _, change := range report.Changes {
+		if change.Error != "" {
+			return 1
+		}
+	}
+	return 0
+}
+
+func printTagMigrationTable(out io.Writer, report *model.TagMigrationReport) {
+	mode := "apply"
+	if report.DryRun {
+		mode = "dry run"
+	}
+	fmt.Fprintf(out, "Scanned %d documents (%s), %d to change, %d tag(s) outside the vocabulary\n\n",
+		report.ScannedDocuments, mode, len(report.Changes), len(report.UnknownTags))
+
+	if len(report.Changes) > 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOCUMENT\tBEFORE\tAFTER\tRESULT")
+		for _, change := range report.Changes {
+			result := "planned"
+			if change.Error != "" {
+				result = "error: " + change.Error
+			} else if change.Applied {
+				result = "applied"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", change.MongoAchievementID,
+				strings.Join(change.Before, ", "), strings.Join(change.After, ", "), result)
+		}
+		w.Flush()
+		fmt.Fprintln(out)
+	}
+
+	if len(report.UnknownTags) > 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "UNKNOWN TAG\tDOCUMENTS")
+		for _, usage := range report.UnknownTags {
+			fmt.Fprintf(w, "%s\t%d\n", usage.Tag, usage.Count)
+		}
+		w.Flush()
+	}
+}
+
+func valueOrDash(value *string) string {
+	if value == nil || *value == "" {
+		return "-"
+	}
+	return *value
+}
@@ -3,16 +3,23 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Postgres PostgresConfig
-	MongoDB  MongoDBConfig
-	JWT      JWTConfig
+	Server    ServerConfig
+	Postgres  PostgresConfig
+	MongoDB   MongoDBConfig
+	JWT       JWTConfig
+	SLA       SLAConfig
+	Outbox    OutboxConfig
+	Report    ReportConfig
+	Cert      CertificateConfig
+	Retention RetentionConfig
+	Expiry    ExpiryConfig
 }
 
 type ServerConfig struct {
@@ -39,6 +46,39 @@ type JWTConfig struct {
 	RefreshExpiration time.Duration
 }
 
+type SLAConfig struct {
+	ReminderAfter  time.Duration
+	EscalateAfter  time.Duration
+	CheckInterval  time.Duration
+	EscalationRole string
+}
+
+type OutboxConfig struct {
+	RelayInterval time.Duration
+	MaxAttempts   int
+}
+
+type ReportConfig struct {
+	InstitutionName string
+}
+
+type CertificateConfig struct {
+	Secret            string
+	PublicURL         string
+	KeySecret         string
+	PreviousKeySecret string
+}
+
+type RetentionConfig struct {
+	Window        time.Duration
+	PurgeInterval time.Duration
+}
+
+type ExpiryConfig struct {
+	NoticeBefore  time.Duration
+	CheckInterval time.Duration
+}
+
 // Load loads configuration from environment variables
 func Load() *Config {
 	// Load .env file
@@ -48,11 +88,34 @@ func Load() *Config {
 
 	jwtExpiration, _ := time.ParseDuration(getEnv("JWT_EXPIRATION", "24h"))
 	jwtRefreshExpiration, _ := time.ParseDuration(getEnv("JWT_REFRESH_EXPIRATION", "168h"))
+	slaReminderAfter, _ := time.ParseDuration(getEnv("SLA_REMINDER_AFTER", "72h"))
+	slaEscalateAfter, _ := time.ParseDuration(getEnv("SLA_ESCALATE_AFTER", "168h"))
+	slaCheckInterval, _ := time.ParseDuration(getEnv("SLA_CHECK_INTERVAL", "1h"))
+	outboxRelayInterval, _ := time.ParseDuration(getEnv("OUTBOX_RELAY_INTERVAL", "30s"))
+	outboxMaxAttempts, _ := strconv.Atoi(getEnv("OUTBOX_MAX_ATTEMPTS", "10"))
+	// A zero window would purge every soft deleted achievement right away
+	retentionWindow, err := time.ParseDuration(getEnv("ACHIEVEMENT_RETENTION", "720h"))
+	if err != nil || retentionWindow <= 0 {
+		log.Fatalf("ACHIEVEMENT_RETENTION must be a positive duration, got %q", os.Getenv("ACHIEVEMENT_RETENTION"))
+	}
+	retentionPurgeInterval, _ := time.ParseDuration(getEnv("ACHIEVEMENT_PURGE_INTERVAL", "24h"))
+	expiryNoticeBefore, _ := time.ParseDuration(getEnv("CERTIFICATION_EXPIRY_NOTICE", "720h"))
+	expiryCheckInterval, _ := time.ParseDuration(getEnv("CERTIFICATION_EXPIRY_CHECK_INTERVAL", "24h"))
+
+	env := getEnv("ENV", "development")
+
+	// The default secret is public, certificates signed with it can be forged
+	if env != "development" && os.Getenv("CERTIFICATE_SECRET") == "" {
+		log.Fatal("CERTIFICATE_SECRET must be set outside development")
+	}
+	if env != "development" && os.Getenv("CREDENTIAL_KEY_SECRET") == "" {
+		log.Fatal("CREDENTIAL_KEY_SECRET must be set outside development")
+	}
 
 	return &Config{
 		Server: ServerConfig{
 			Port: getEnv("PORT", "3000"),
-			Env:  getEnv("ENV", "development"),
+			Env:  env,
 		},
 		Postgres: PostgresConfig{
 			Host:     getEnv("POSTGRES_HOST", "localhost"),
@@ -70,6 +133,33 @@ func Load() *Config {
 			Expiration:        jwtExpiration,
 			RefreshExpiration: jwtRefreshExpiration,
 		},
+		SLA: SLAConfig{
+			ReminderAfter:  slaReminderAfter,
+			EscalateAfter:  slaEscalateAfter,
+			CheckInterval:  slaCheckInterval,
+			EscalationRole: getEnv("SLA_ESCALATION_ROLE", "Admin"),
+		},
+		Outbox: OutboxConfig{
+			RelayInterval: outboxRelayInterval,
+			MaxAttempts:   outboxMaxAttempts,
+		},
+		Report: ReportConfig{
+			InstitutionName: getEnv("INSTITUTION_NAME", "Universitas"),
+		},
+		Cert: CertificateConfig{
+			Secret:            getEnv("CERTIFICATE_SECRET", "your-certificate-secret"),
+			PublicURL:         getEnv("PUBLIC_BASE_URL", "http://localhost:3000"),
+			KeySecret:         getEnv("CREDENTIAL_KEY_SECRET", "your-credential-key-secret"),
+			PreviousKeySecret: os.Getenv("CREDENTIAL_KEY_PREVIOUS_SECRET"),
+		},
+		Retention: RetentionConfig{
+			Window:        retentionWindow,
+			PurgeInterval: retentionPurgeInterval,
+		},
+		Expiry: ExpiryConfig{
+			NoticeBefore:  expiryNoticeBefore,
+			CheckInterval: expiryCheckInterval,
+		},
 	}
 }
 
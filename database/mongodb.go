@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -28,9 +29,54 @@ func ConnectMongoDB(uri, dbname string) error {
 	MongoDB = client.Database(dbname)
 	log.Println("Connected to MongoDB")
 
+	// Initialize indexes
+	if err := initializeIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to initialize indexes: %w", err)
+	}
+
 	return nil
 }
 
+func initializeIndexes(ctx context.Context) error {
+	_, err := MongoDB.Collection("achievement_revisions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "achievementId", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Lookups used by duplicate detection
+	_, err = MongoDB.Collection("achievements").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "details.certificationNumber", Value: 1}}},
+		{Keys: bson.D{{Key: "attachments.checksum", Value: 1}}},
+		{Keys: bson.D{{Key: "studentId", Value: 1}, {Key: "achievementType", Value: 1}}},
+		{Keys: bson.D{{Key: "achievementType", Value: 1}, {Key: "details.validUntil", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Full-text search. Content is mostly Indonesian, which MongoDB cannot
+	// stem, so stemming and stop words are disabled.
+	_, err = MongoDB.Collection("achievements").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "title", Value: "text"},
+			{Key: "description", Value: "text"},
+			{Key: "tags", Value: "text"},
+			{Key: "details.competitionName", Value: "text"},
+			{Key: "details.certificationName", Value: "text"},
+			{Key: "details.organizationName", Value: "text"},
+			{Key: "details.publicationTitle", Value: "text"},
+		},
+		Options: options.Index().
+			SetName("achievement_text").
+			SetDefaultLanguage("none").
+			SetWeights(bson.D{{Key: "title", Value: 10}, {Key: "tags", Value: 5}, {Key: "details.competitionName", Value: 5}, {Key: "details.certificationName", Value: 5}}),
+	})
+	return err
+}
+
 func CloseMongoDB() {
 	if MongoDB != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -74,6 +74,8 @@ func initializeTables() error {
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
 
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
 	-- Create lecturers table
 	CREATE TABLE IF NOT EXISTS lecturers (
 		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -99,7 +101,7 @@ func initializeTables() error {
 		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 		student_id UUID REFERENCES students(id) ON DELETE CASCADE,
 		mongo_achievement_id VARCHAR(24) NOT NULL,
-		status VARCHAR(20) DEFAULT 'draft' CHECK (status IN ('draft', 'submitted', 'verified', 'rejected')),
+		status VARCHAR(20) DEFAULT 'draft',
 		submitted_at TIMESTAMP,
 		verified_at TIMESTAMP,
 		verified_by UUID REFERENCES users(id),
@@ -110,6 +112,288 @@ func initializeTables() error {
 
 	CREATE INDEX IF NOT EXISTS idx_achievement_student ON achievement_references(student_id);
 	CREATE INDEX IF NOT EXISTS idx_achievement_status ON achievement_references(status);
+
+	ALTER TABLE achievement_references DROP CONSTRAINT IF EXISTS achievement_references_status_check;
+	ALTER TABLE achievement_references ADD CONSTRAINT achievement_references_status_check
+		CHECK (status IN ('draft', 'submitted', 'verified', 'rejected', 'needs_revision', 'withdrawn', 'revoked'));
+
+	-- Create achievement_comments table
+	CREATE TABLE IF NOT EXISTS achievement_comments (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+		parent_id UUID REFERENCES achievement_comments(id) ON DELETE CASCADE,
+		author_id UUID NOT NULL REFERENCES users(id),
+		kind VARCHAR(20) NOT NULL DEFAULT 'comment',
+		body TEXT NOT NULL,
+		revision INT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		edited_at TIMESTAMP,
+		deleted_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_comment_achievement ON achievement_comments(achievement_ref_id);
+
+	ALTER TABLE achievement_comments DROP CONSTRAINT IF EXISTS achievement_comments_kind_check;
+	ALTER TABLE achievement_comments ADD CONSTRAINT achievement_comments_kind_check
+		CHECK (kind IN ('comment', 'rejection', 'revision_request', 'revocation'));
+
+	-- Create approval chain tables
+	CREATE TABLE IF NOT EXISTS approval_chains (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		name VARCHAR(100) NOT NULL,
+		achievement_type VARCHAR(50),
+		competition_level VARCHAR(50),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS approval_chain_steps (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		chain_id UUID NOT NULL REFERENCES approval_chains(id) ON DELETE CASCADE,
+		step_order INT NOT NULL,
+		name VARCHAR(100) NOT NULL,
+		allowed_roles TEXT[] NOT NULL,
+		UNIQUE (chain_id, step_order)
+	);
+
+	-- Create achievement_approvals table, one row per review decision
+	CREATE TABLE IF NOT EXISTS achievement_approvals (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+		step_order INT NOT NULL,
+		step_name VARCHAR(100) NOT NULL,
+		approver_id UUID NOT NULL REFERENCES users(id),
+		action VARCHAR(20) NOT NULL CHECK (action IN ('approve', 'reject', 'request_revision')),
+		note TEXT,
+		submitted_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_approval_achievement ON achievement_approvals(achievement_ref_id);
+
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS approval_step INT NOT NULL DEFAULT 0;
+
+	-- Verification SLA tracking
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS reminder_sent_at TIMESTAMP;
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP;
+
+	CREATE INDEX IF NOT EXISTS idx_achievement_submitted_at ON achievement_references(submitted_at) WHERE status = 'submitted';
+
+	-- Create notifications table
+	CREATE TABLE IF NOT EXISTS notifications (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		type VARCHAR(50) NOT NULL,
+		title VARCHAR(200) NOT NULL,
+		message TEXT NOT NULL,
+		achievement_ref_id UUID REFERENCES achievement_references(id) ON DELETE CASCADE,
+		read_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_notification_user ON notifications(user_id, created_at DESC);
+
+	-- Team achievements: participating students and how points are shared
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS team_point_rule VARCHAR(20) NOT NULL DEFAULT 'full';
+
+	ALTER TABLE achievement_references DROP CONSTRAINT IF EXISTS achievement_references_team_point_rule_check;
+	ALTER TABLE achievement_references ADD CONSTRAINT achievement_references_team_point_rule_check
+		CHECK (team_point_rule IN ('full', 'equal'));
+
+	CREATE TABLE IF NOT EXISTS achievement_members (
+		achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+		student_id UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+		role VARCHAR(20) NOT NULL CHECK (role IN ('leader', 'member')),
+		status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'declined')),
+		responded_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (achievement_ref_id, student_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_member_student ON achievement_members(student_id);
+
+	-- Duplicate detection
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS duplicate_of UUID REFERENCES achievement_references(id) ON DELETE SET NULL;
+
+	CREATE TABLE IF NOT EXISTS achievement_duplicate_flags (
+		achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+		candidate_ref_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+		score NUMERIC(4, 3) NOT NULL,
+		reasons TEXT[] NOT NULL,
+		detected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (achievement_ref_id, candidate_ref_id)
+	);
+
+	-- Keyset pagination on (created_at, id)
+	CREATE INDEX IF NOT EXISTS idx_achievement_created_id ON achievement_references(created_at DESC, id DESC);
+	CREATE INDEX IF NOT EXISTS idx_users_created_id ON users(created_at DESC, id DESC) WHERE deleted_at IS NULL;
+
+	-- Outbox of MongoDB changes, written in the same transaction as the reference.
+	-- No foreign key: delete events outlive their reference.
+	CREATE TABLE IF NOT EXISTS achievement_outbox (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		achievement_ref_id UUID NOT NULL,
+		mongo_achievement_id VARCHAR(24) NOT NULL,
+		operation VARCHAR(30) NOT NULL CHECK (operation IN ('create_document', 'delete_document')),
+		payload JSONB,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'done', 'failed', 'compensated')),
+		attempts INT NOT NULL DEFAULT 0,
+		last_error TEXT,
+		next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		processed_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_outbox_pending ON achievement_outbox(next_attempt_at) WHERE status = 'pending';
+
+	-- Finalized SKPI achievement sections, frozen and never updated
+	CREATE TABLE IF NOT EXISTS skpi_snapshots (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		student_id UUID NOT NULL UNIQUE REFERENCES students(id) ON DELETE CASCADE,
+		document JSONB NOT NULL,
+		finalized_by UUID NOT NULL REFERENCES users(id),
+		finalized_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Open Badges credentials. The single issuer key signs every credential;
+	-- status_index is the credential's entry in the revocation status list.
+	CREATE TABLE IF NOT EXISTS credential_issuer_keys (
+		id INT PRIMARY KEY CHECK (id = 1),
+		public_key BYTEA NOT NULL,
+		private_key BYTEA NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE SEQUENCE IF NOT EXISTS credential_status_index_seq MINVALUE 1 MAXVALUE 131072;
+
+	-- No foreign key: a credential outlives its reference so it stays revoked
+	CREATE TABLE IF NOT EXISTS achievement_credentials (
+		id UUID PRIMARY KEY,
+		achievement_ref_id UUID NOT NULL,
+		status_index INT NOT NULL UNIQUE,
+		credential JSONB NOT NULL,
+		issued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		revoked_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_credentials_reference ON achievement_credentials(achievement_ref_id);
+
+	-- Only one active credential per achievement; concurrent issuing could
+	-- leave extra copies, only the latest of them stays active
+	UPDATE achievement_credentials c
+	SET revoked_at = CURRENT_TIMESTAMP
+	WHERE c.revoked_at IS NULL AND EXISTS (
+		SELECT 1 FROM achievement_credentials newer
+		WHERE newer.achievement_ref_id = c.achievement_ref_id AND newer.revoked_at IS NULL
+		  AND (newer.issued_at, newer.id) > (c.issued_at, c.id)
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_credentials_active_reference
+		ON achievement_credentials(achievement_ref_id) WHERE revoked_at IS NULL;
+
+	-- Revocation of verified achievements and the history of every status change
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP;
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS revoked_by UUID REFERENCES users(id);
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS revocation_reason TEXT;
+
+	CREATE TABLE IF NOT EXISTS achievement_status_history (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+		from_status VARCHAR(20) NOT NULL,
+		to_status VARCHAR(20) NOT NULL,
+		action VARCHAR(30) NOT NULL,
+		actor_id UUID REFERENCES users(id),
+		note TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_status_history_achievement ON achievement_status_history(achievement_ref_id, created_at);
+
+	-- Soft delete of achievements, purged once the retention window passes
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS deleted_by UUID REFERENCES users(id);
+
+	CREATE INDEX IF NOT EXISTS idx_achievement_references_deleted ON achievement_references(deleted_at) WHERE deleted_at IS NOT NULL;
+
+	-- Certification expiry, taken from the document's validUntil, and renewals
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS expired_at TIMESTAMP;
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS expiry_notified_at TIMESTAMP;
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS renewal_of UUID REFERENCES achievement_references(id) ON DELETE SET NULL;
+	CREATE INDEX IF NOT EXISTS idx_achievement_references_unexpired ON achievement_references(mongo_achievement_id)
+		WHERE status = 'verified' AND expired_at IS NULL AND deleted_at IS NULL;
+
+	-- Curated tag vocabulary. normalized is the lowercase, single-spaced form
+	-- names and synonyms are matched on, unique within each table.
+	CREATE TABLE IF NOT EXISTS tags (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		name VARCHAR(100) NOT NULL,
+		normalized VARCHAR(100) NOT NULL UNIQUE,
+		parent_id UUID REFERENCES tags(id) ON DELETE SET NULL,
+		description TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS tag_synonyms (
+		normalized VARCHAR(100) PRIMARY KEY,
+		tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+		synonym VARCHAR(100) NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tag_synonyms_tag ON tag_synonyms(tag_id);
+
+	-- Academic periods (semesters). Achievements are assigned the period their
+	-- event date, or creation time, falls in. At most one period is active.
+	CREATE TABLE IF NOT EXISTS academic_periods (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		code VARCHAR(20) NOT NULL UNIQUE,
+		name VARCHAR(100) NOT NULL,
+		start_date DATE NOT NULL,
+		end_date DATE NOT NULL CHECK (end_date >= start_date),
+		is_active BOOLEAN NOT NULL DEFAULT false,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_academic_periods_active ON academic_periods(is_active) WHERE is_active;
+
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS period_id UUID REFERENCES academic_periods(id) ON DELETE SET NULL;
+
+	CREATE INDEX IF NOT EXISTS idx_achievement_references_period ON achievement_references(period_id);
+
+	-- Minimum verified achievement points (SKKM) required to graduate, per
+	-- program study. An empty cohort is the program study default.
+	CREATE TABLE IF NOT EXISTS graduation_requirements (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		program_study VARCHAR(100) NOT NULL,
+		cohort VARCHAR(10) NOT NULL DEFAULT '',
+		min_points INTEGER NOT NULL CHECK (min_points >= 0),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (program_study, cohort)
+	);
+
+	CREATE TABLE IF NOT EXISTS graduation_requirement_categories (
+		requirement_id UUID NOT NULL REFERENCES graduation_requirements(id) ON DELETE CASCADE,
+		category VARCHAR(50) NOT NULL,
+		min_points INTEGER NOT NULL CHECK (min_points > 0),
+		PRIMARY KEY (requirement_id, category)
+	);
+
+	-- Approval chain an achievement was submitted under, kept so editing or
+	-- deleting the chain does not affect items already in review
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS approval_chain_id UUID;
+	ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS approval_steps JSONB;
+
+	-- Move rejection notes written before comments existed into the thread
+	INSERT INTO achievement_comments (achievement_ref_id, author_id, kind, body, created_at, updated_at)
+	SELECT ar.id, ar.verified_by, 'rejection', ar.rejection_note, ar.updated_at, ar.updated_at
+	FROM achievement_references ar
+	WHERE ar.rejection_note IS NOT NULL AND ar.rejection_note <> '' AND ar.verified_by IS NOT NULL
+	  AND NOT EXISTS (
+		SELECT 1 FROM achievement_comments c
+		WHERE c.achievement_ref_id = ar.id AND c.kind = 'rejection'
+	  );
 	`
 
 	_, err := PostgresDB.Exec(schema)
@@ -122,6 +406,10 @@ func initializeTables() error {
 		return err
 	}
 
+	if err := seedApprovalDefaults(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -221,6 +509,59 @@ func seedDefaultData() error {
 	return nil
 }
 
+// seedApprovalDefaults adds the Kaprodi role and the approval chain for
+// international competitions. Safe to run on every start.
+func seedApprovalDefaults() error {
+	_, err := PostgresDB.Exec(`
+		INSERT INTO roles (name, description)
+		VALUES ('Kaprodi', 'Head of study program who approves high-value achievements')
+		ON CONFLICT (name) DO NOTHING
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = PostgresDB.Exec(`
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT r.id, p.id FROM roles r, permissions p
+		WHERE r.name = 'Kaprodi' AND p.name IN ('achievement:read', 'achievement:verify', 'report:view')
+		ON CONFLICT DO NOTHING
+	`)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err := PostgresDB.QueryRow("SELECT COUNT(*) FROM approval_chains").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var chainID string
+	err = PostgresDB.QueryRow(`
+		INSERT INTO approval_chains (name, achievement_type, competition_level)
+		VALUES ('International competition', 'competition', 'international')
+		RETURNING id
+	`).Scan(&chainID)
+	if err != nil {
+		return err
+	}
+
+	_, err = PostgresDB.Exec(`
+		INSERT INTO approval_chain_steps (chain_id, step_order, name, allowed_roles) VALUES
+		($1, 1, 'Dosen Wali', '{"Dosen Wali"}'),
+		($1, 2, 'Kaprodi / Kemahasiswaan', '{"Kaprodi", "Admin"}')
+	`, chainID)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Default approval chains seeded")
+	return nil
+}
+
 func ClosePostgres() {
 	if PostgresDB != nil {
 		PostgresDB.Close()
@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"projek_uas/app/model"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+// WriteCertificatePDF renders a one page landscape certificate with a QR
+// code linking to the public verification URL
+func WriteCertificatePDF(w io.Writer, certificate *model.Certificate) error {
+	qr, err := qrcode.Encode(certificate.VerificationURL, qrcode.Medium, 512)
+	if err != nil {
+		return err
+	}
+
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(25, 25, 25)
+	pdf.SetAutoPageBreak(false, 0)
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	pdf.SetLineWidth(1.2)
+	pdf.Rect(10, 10, pageWidth-20, pageHeight-20, "D")
+	pdf.SetLineWidth(0.3)
+	pdf.Rect(13, 13, pageWidth-26, pageHeight-26, "D")
+
+	pdf.SetY(28)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 8, translate(certificate.Institution), "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "B", 26)
+	pdf.CellFormat(0, 12, "SERTIFIKAT PRESTASI", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "I", 13)
+	pdf.CellFormat(0, 7, "Certificate of Achievement", "", 1, "C", false, 0, "")
+	pdf.Ln(8)
+
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, "Diberikan kepada / Awarded to", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.CellFormat(0, 11, translate(certificate.StudentName), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, translate(fmt.Sprintf("NIM %s - %s", certificate.StudentNumber, certificate.ProgramStudy)), "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.MultiCell(0, 7, translate(certificate.Description.ID), "", "C", false)
+	pdf.SetFont("Helvetica", "I", 11)
+	pdf.MultiCell(0, 6, translate(certificate.Description.EN), "", "C", false)
+
+	const qrSize = 38
+	qrX, qrY := pageWidth-25-qrSize, pageHeight-25-qrSize
+	pdf.RegisterImageOptionsReader("qr", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qr))
+	pdf.ImageOptions("qr", qrX, qrY, qrSize, qrSize, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+	pdf.SetXY(25, pageHeight-25-qrSize+8)
+	pdf.SetFont("Helvetica", "", 10)
+	lines := []string{
+		fmt.Sprintf("Diverifikasi / Verified: %s", certificate.VerifiedAt.Format("02-01-2006")),
+		fmt.Sprintf("Oleh / By: %s", certificate.VerifiedBy),
+		"Pindai kode QR untuk memeriksa keaslian sertifikat ini.",
+	}
+	for _, line := range lines {
+		pdf.CellFormat(qrX-30, 6, translate(line), "", 2, "L", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "I", 9)
+	pdf.CellFormat(qrX-30, 5, "Scan the QR code to check that this certificate is genuine.", "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 7)
+	pdf.CellFormat(qrX-30, 5, translate(certificate.VerificationURL), "", 2, "L", false, 0, "")
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
@@ -0,0 +1,55 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// certificateMACSize is how many bytes of the HMAC are kept in a token
+const certificateMACSize = 16
+
+var errInvalidCertificateToken = errors.New("invalid certificate token")
+
+// SignCertificateToken makes the token printed on an achievement's
+// certificate: the reference id followed by a truncated HMAC-SHA256 of it,
+// base64url encoded. Tokens cannot be derived without the secret.
+func SignCertificateToken(secret, achievementRefID string) (string, error) {
+	id, err := uuid.Parse(achievementRefID)
+	if err != nil {
+		return "", err
+	}
+
+	data := append(id[:], certificateMAC(secret, id[:])...)
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// VerifyCertificateToken checks the token's signature and returns the
+// reference id it was issued for
+func VerifyCertificateToken(secret, token string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) != len(uuid.UUID{})+certificateMACSize {
+		return "", errInvalidCertificateToken
+	}
+
+	idBytes, mac := data[:len(uuid.UUID{})], data[len(uuid.UUID{}):]
+	if !hmac.Equal(mac, certificateMAC(secret, idBytes)) {
+		return "", errInvalidCertificateToken
+	}
+
+	id, err := uuid.FromBytes(idBytes)
+	if err != nil {
+		return "", errInvalidCertificateToken
+	}
+	return id.String(), nil
+}
+
+func certificateMAC(secret string, id []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("certificate:"))
+	mac.Write(id)
+	return mac.Sum(nil)[:certificateMACSize]
+}
@@ -0,0 +1,166 @@
+package helper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// CredentialCryptosuite is the Data Integrity cryptosuite used to sign
+// credentials: Ed25519 over JCS canonicalized JSON
+const CredentialCryptosuite = "eddsa-jcs-2022"
+
+// StatusListSize is the number of entries in a status list, the 16KB
+// minimum the Bitstring Status List specification asks for so a list does
+// not reveal much about individual credentials
+const StatusListSize = 131072
+
+// AddDataIntegrityProof signs the document with an eddsa-jcs-2022 proof and
+// stores it under "proof"
+func AddDataIntegrityProof(document map[string]interface{}, key ed25519.PrivateKey, verificationMethod string, created time.Time) error {
+	proof := map[string]interface{}{
+		"type":               "DataIntegrityProof",
+		"cryptosuite":        CredentialCryptosuite,
+		"created":            created.UTC().Format(time.RFC3339),
+		"verificationMethod": verificationMethod,
+		"proofPurpose":       "assertionMethod",
+	}
+	if context, ok := document["@context"]; ok {
+		proof["@context"] = context
+	}
+
+	delete(document, "proof")
+	hash, err := proofHash(document, proof)
+	if err != nil {
+		return err
+	}
+
+	proof["proofValue"] = EncodeBase58BTC(ed25519.Sign(key, hash))
+	document["proof"] = proof
+	return nil
+}
+
+// VerifyDataIntegrityProof checks the eddsa-jcs-2022 proof of a decoded
+// document. resolve returns the public key of the proof's verification
+// method, so nothing has to be fetched over the network.
+func VerifyDataIntegrityProof(document map[string]interface{}, resolve func(verificationMethod string) (ed25519.PublicKey, error)) error {
+	proof, ok := document["proof"].(map[string]interface{})
+	if !ok {
+		return errors.New("credential has no proof")
+	}
+	if proof["type"] != "DataIntegrityProof" || proof["cryptosuite"] != CredentialCryptosuite {
+		return fmt.Errorf("unsupported proof, expected a DataIntegrityProof with %s", CredentialCryptosuite)
+	}
+	if proof["proofPurpose"] != "assertionMethod" {
+		return errors.New("proof purpose must be assertionMethod")
+	}
+
+	proofValue, _ := proof["proofValue"].(string)
+	signature, err := DecodeBase58BTC(proofValue)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return errors.New("invalid proof value")
+	}
+	verificationMethod, _ := proof["verificationMethod"].(string)
+	publicKey, err := resolve(verificationMethod)
+	if err != nil {
+		return err
+	}
+
+	unsecured := make(map[string]interface{}, len(document))
+	for key, value := range document {
+		if key != "proof" {
+			unsecured[key] = value
+		}
+	}
+	options := make(map[string]interface{}, len(proof))
+	for key, value := range proof {
+		if key != "proofValue" {
+			options[key] = value
+		}
+	}
+	if context, ok := options["@context"]; ok && !reflect.DeepEqual(context, unsecured["@context"]) {
+		return errors.New("proof context does not match the credential context")
+	}
+
+	hash, err := proofHash(unsecured, options)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, hash, signature) {
+		return errors.New("proof signature does not match the credential")
+	}
+	return nil
+}
+
+// proofHash is the data signed by eddsa-jcs-2022: the SHA-256 of the
+// canonical proof options followed by the SHA-256 of the canonical document
+func proofHash(document, options map[string]interface{}) ([]byte, error) {
+	canonicalOptions, err := CanonicalizeValue(options)
+	if err != nil {
+		return nil, err
+	}
+	canonicalDocument, err := CanonicalizeValue(document)
+	if err != nil {
+		return nil, err
+	}
+
+	optionsHash := sha256.Sum256(canonicalOptions)
+	documentHash := sha256.Sum256(canonicalDocument)
+	return append(optionsHash[:], documentHash[:]...), nil
+}
+
+// EncodeStatusList builds the encodedList of a Bitstring Status List with
+// the given entries set: the bitstring, GZIP compressed and multibase
+// base64url encoded. Entry 0 is the most significant bit of the first byte.
+func EncodeStatusList(setIndexes []int) (string, error) {
+	bits := make([]byte, StatusListSize/8)
+	for _, index := range setIndexes {
+		if index < 0 || index >= StatusListSize {
+			return "", fmt.Errorf("status list index %d out of range", index)
+		}
+		bits[index/8] |= 0x80 >> (index % 8)
+	}
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(bits); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return "u" + base64.RawURLEncoding.EncodeToString(buffer.Bytes()), nil
+}
+
+// StatusListEntrySet reports whether the entry is set in an encodedList
+func StatusListEntrySet(encodedList string, index int) (bool, error) {
+	if len(encodedList) == 0 || encodedList[0] != 'u' {
+		return false, errors.New("status list is not multibase base64url encoded")
+	}
+	compressed, err := base64.RawURLEncoding.DecodeString(encodedList[1:])
+	if err != nil {
+		return false, err
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return false, err
+	}
+	bits, err := io.ReadAll(io.LimitReader(reader, StatusListSize))
+	if err != nil {
+		return false, err
+	}
+	if index < 0 || index/8 >= len(bits) {
+		return false, fmt.Errorf("status list index %d out of range", index)
+	}
+
+	return bits[index/8]&(0x80>>(index%8)) != 0, nil
+}
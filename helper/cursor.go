@@ -0,0 +1,104 @@
+package helper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+
+	"projek_uas/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// EncodeCursor turns a listing position into an opaque token
+func EncodeCursor(cursor model.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a token produced by EncodeCursor. An empty token means
+// the first page and returns nil.
+func DecodeCursor(token string) (*model.Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor model.Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || cursor.CreatedAt.IsZero() {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+// NewCursorPagination builds the page meta from the cursor the page was
+// requested with, whether more rows exist in that direction and the
+// positions of the first and last row shown (nil for an empty page).
+func NewCursorPagination(limit int, cursor *model.Cursor, more bool, first, last *model.Cursor) model.CursorPagination {
+	meta := model.CursorPagination{Limit: limit}
+
+	backward := cursor != nil && cursor.Backward
+	hasNext := (!backward && more) || backward
+	hasPrev := cursor != nil && (!backward || more)
+
+	if first == nil || last == nil {
+		// Nothing in the requested direction, offer the way back
+		if cursor != nil {
+			token := EncodeCursor(model.Cursor{CreatedAt: cursor.CreatedAt, ID: cursor.ID, Backward: !backward})
+			if backward {
+				meta.NextCursor = &token
+			} else {
+				meta.PrevCursor = &token
+			}
+		}
+		return meta
+	}
+
+	if hasNext {
+		token := EncodeCursor(model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		meta.NextCursor = &token
+	}
+	if hasPrev {
+		token := EncodeCursor(model.Cursor{CreatedAt: first.CreatedAt, ID: first.ID, Backward: true})
+		meta.PrevCursor = &token
+	}
+
+	return meta
+}
+
+// CursorPaginatedResponse writes a keyset page, filling in the next and prev
+// links from the current request URL
+func CursorPaginatedResponse(c *fiber.Ctx, data interface{}, meta model.CursorPagination) error {
+	meta.Next = cursorLink(c, meta.NextCursor)
+	meta.Prev = cursorLink(c, meta.PrevCursor)
+
+	return c.JSON(model.CursorPaginatedResponse{
+		Status: "success",
+		Data:   data,
+		Meta:   meta,
+	})
+}
+
+func cursorLink(c *fiber.Ctx, cursor *string) *string {
+	if cursor == nil {
+		return nil
+	}
+
+	query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	query.Set("cursor", *cursor)
+	query.Del("page")
+	link := c.BaseURL() + c.Path() + "?" + query.Encode()
+	return &link
+}
+
+// UseCursorPagination reports whether a listing request asks for keyset
+// pagination instead of page numbers
+func UseCursorPagination(c *fiber.Ctx) bool {
+	return c.Query("cursor") != "" || c.Query("pagination") == "cursor"
+}
@@ -0,0 +1,93 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"projek_uas/app/model"
+)
+
+// DiffFields compares two values field by field using their JSON
+// representation and returns the changed leaf fields as dotted paths,
+// e.g. "details.competition_level" or "tags.1".
+func DiffFields(oldValue, newValue interface{}) ([]model.FieldChange, error) {
+	oldFields, err := flattenJSON(oldValue)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := flattenJSON(newValue)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]struct{})
+	for k := range oldFields {
+		keys[k] = struct{}{}
+	}
+	for k := range newFields {
+		keys[k] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	changes := []model.FieldChange{}
+	for _, k := range sorted {
+		oldField, newField := oldFields[k], newFields[k]
+		if reflect.DeepEqual(oldField, newField) {
+			continue
+		}
+		changes = append(changes, model.FieldChange{
+			Field:    k,
+			OldValue: oldField,
+			NewValue: newField,
+		})
+	}
+
+	return changes, nil
+}
+
+func flattenJSON(value interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+	flatten("", decoded, fields)
+	return fields, nil
+}
+
+func flatten(prefix string, value interface{}, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			flatten(joinPath(prefix, k), child, fields)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(joinPath(prefix, fmt.Sprint(i)), child, fields)
+		}
+	default:
+		if prefix != "" {
+			fields[prefix] = v
+		}
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,126 @@
+package helper
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"projek_uas/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExportRowFunc receives one row of an export. Values are strings, ints,
+// float64s, time.Time or *time.Time values, or nil for an empty cell.
+type ExportRowFunc func(row []interface{}) error
+
+// ExportTable describes a report to export. Rows is called once the response
+// has started and emits the rows one by one, so large reports never have to
+// be held in memory as a whole.
+type ExportTable struct {
+	Title    string
+	Subtitle string
+	Columns  []model.ExportColumn
+	Rows     func(emit ExportRowFunc) error
+}
+
+// ParseExportFormat reads the format query parameter. An empty result means
+// the client asked for JSON.
+func ParseExportFormat(c *fiber.Ctx) (string, error) {
+	switch format := c.Query("format"); format {
+	case "", "json":
+		return "", nil
+	case model.ExportFormatCSV, model.ExportFormatXLSX, model.ExportFormatPDF:
+		return format, nil
+	}
+	return "", errors.New("format must be json, csv, xlsx or pdf")
+}
+
+var exportContentTypes = map[string]string{
+	model.ExportFormatCSV:  "text/csv; charset=utf-8",
+	model.ExportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	model.ExportFormatPDF:  "application/pdf",
+}
+
+// SendExport streams the table in the given format as a download. The
+// institution name heads PDF exports. Errors after the response has started
+// can no longer change its status and are only logged.
+func SendExport(c *fiber.Ctx, format, filename, institution string, table ExportTable) error {
+	c.Set(fiber.HeaderContentType, exportContentTypes[format])
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-%s.%s"`, filename, time.Now().Format("20060102"), format))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var err error
+		switch format {
+		case model.ExportFormatCSV:
+			err = writeCSV(w, table)
+		case model.ExportFormatXLSX:
+			err = writeXLSX(w, table)
+		case model.ExportFormatPDF:
+			err = writePDF(w, table, institution)
+		}
+		if err != nil {
+			log.Printf("export %s as %s failed: %v", filename, format, err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+func writeCSV(w io.Writer, table ExportTable) error {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column.Header
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	err := table.Rows(func(row []interface{}) error {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatExportValue(value)
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatExportValue renders a cell as text, dates as YYYY-MM-DD
+func formatExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format("2006-01-02")
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format("2006-01-02")
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,61 @@
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+)
+
+// sealedKeyVersion prefixes a sealed issuer key so the format can change
+// later without guessing
+const sealedKeyVersion = 1
+
+var errInvalidSealedKey = errors.New("issuer key cannot be decrypted")
+
+// SealIssuerKey encrypts the issuer's private key seed with AES-256-GCM under
+// a key derived from the secret, so a database dump alone cannot sign
+// credentials
+func SealIssuerKey(secret string, seed []byte) ([]byte, error) {
+	aead, err := issuerKeyCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := append([]byte{sealedKeyVersion}, nonce...)
+	return aead.Seal(sealed, nonce, seed, []byte("issuer-key")), nil
+}
+
+// OpenIssuerKey decrypts a seed sealed by SealIssuerKey. It fails when the
+// secret is not the one the seed was sealed with.
+func OpenIssuerKey(secret string, sealed []byte) ([]byte, error) {
+	aead, err := issuerKeyCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < 1+aead.NonceSize() || sealed[0] != sealedKeyVersion {
+		return nil, errInvalidSealedKey
+	}
+
+	nonce, ciphertext := sealed[1:1+aead.NonceSize()], sealed[1+aead.NonceSize():]
+	seed, err := aead.Open(nil, nonce, ciphertext, []byte("issuer-key"))
+	if err != nil {
+		return nil, errInvalidSealedKey
+	}
+	return seed, nil
+}
+
+func issuerKeyCipher(secret string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("issuer-key:" + secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
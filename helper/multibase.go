@@ -0,0 +1,82 @@
+package helper
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// ed25519MulticodecPrefix marks an Ed25519 public key in a Multikey
+var ed25519MulticodecPrefix = []byte{0xed, 0x01}
+
+// EncodeBase58BTC returns the data as multibase base58btc, the "z" prefixed
+// encoding used for Data Integrity proofs and Multikeys
+func EncodeBase58BTC(data []byte) string {
+	number := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var encoded []byte
+	for number.Sign() > 0 {
+		number.DivMod(number, radix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return "z" + string(encoded)
+}
+
+// DecodeBase58BTC reverses EncodeBase58BTC
+func DecodeBase58BTC(value string) ([]byte, error) {
+	if !strings.HasPrefix(value, "z") {
+		return nil, errors.New("value is not multibase base58btc encoded")
+	}
+	value = value[1:]
+
+	number := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range value {
+		digit := strings.IndexRune(base58Alphabet, r)
+		if digit < 0 {
+			return nil, errors.New("invalid base58 character")
+		}
+		number.Mul(number, radix)
+		number.Add(number, big.NewInt(int64(digit)))
+	}
+
+	decoded := number.Bytes()
+	zeros := 0
+	for zeros < len(value) && value[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), decoded...), nil
+}
+
+// EncodeMultikey returns the publicKeyMultibase of an Ed25519 public key
+func EncodeMultikey(publicKey ed25519.PublicKey) string {
+	return EncodeBase58BTC(append(append([]byte{}, ed25519MulticodecPrefix...), publicKey...))
+}
+
+// DecodeMultikey reads an Ed25519 publicKeyMultibase
+func DecodeMultikey(value string) (ed25519.PublicKey, error) {
+	data, err := DecodeBase58BTC(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != len(ed25519MulticodecPrefix)+ed25519.PublicKeySize ||
+		data[0] != ed25519MulticodecPrefix[0] || data[1] != ed25519MulticodecPrefix[1] {
+		return nil, errors.New("not an Ed25519 multikey")
+	}
+	return ed25519.PublicKey(data[len(ed25519MulticodecPrefix):]), nil
+}
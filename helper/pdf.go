@@ -0,0 +1,83 @@
+package helper
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"projek_uas/app/model"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// writePDF renders the table on landscape A4 pages under a header with the
+// institution name and report title. The header row is repeated on every
+// page and cells too long for their column are shortened.
+func writePDF(w io.Writer, table ExportTable, institution string) error {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	columnWidth := (pageWidth - left - right) / float64(len(table.Columns))
+	generatedAt := time.Now().Format("02-01-2006 15:04")
+
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 7, translate(institution), "", 1, "C", false, 0, "")
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(0, 6, translate(table.Title), "", 1, "C", false, 0, "")
+		if table.Subtitle != "" {
+			pdf.SetFont("Helvetica", "I", 9)
+			pdf.CellFormat(0, 5, translate(table.Subtitle), "", 1, "C", false, 0, "")
+		}
+		pdf.Ln(3)
+
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetFillColor(230, 230, 230)
+		for _, column := range table.Columns {
+			pdf.CellFormat(columnWidth, 6, fitText(pdf, translate(column.Header), columnWidth), "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 8)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 7)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Dibuat %s - Halaman %d", generatedAt, pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	err := table.Rows(func(row []interface{}) error {
+		for i, value := range row {
+			align := "L"
+			if i < len(table.Columns) && table.Columns[i].Type == model.ExportColumnNumber {
+				align = "R"
+			}
+			text := translate(formatExportValue(value))
+			pdf.CellFormat(columnWidth, 5, fitText(pdf, text, columnWidth), "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+		return pdf.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	return pdf.Output(w)
+}
+
+// fitText shortens already translated, single byte text with an ellipsis so
+// it fits within width at the current font
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	const padding = 2
+	if pdf.GetStringWidth(text) <= width-padding {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > width-padding {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
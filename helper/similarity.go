@@ -0,0 +1,43 @@
+package helper
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeText lowercases the text and reduces it to words separated by
+// single spaces, dropping punctuation.
+func NormalizeText(text string) string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// TextSimilarity returns the Jaccard similarity of the words of two texts,
+// from 0 (nothing in common) to 1 (same words).
+func TextSimilarity(a, b string) float64 {
+	wordsA := strings.Fields(NormalizeText(a))
+	wordsB := strings.Fields(NormalizeText(b))
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	setA := make(map[string]bool, len(wordsA))
+	for _, w := range wordsA {
+		setA[w] = true
+	}
+	setB := make(map[string]bool, len(wordsB))
+	for _, w := range wordsB {
+		setB[w] = true
+	}
+
+	common := 0
+	for w := range setA {
+		if setB[w] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(setA)+len(setB)-common)
+}
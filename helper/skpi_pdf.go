@@ -0,0 +1,86 @@
+package helper
+
+import (
+	"fmt"
+	"io"
+
+	"projek_uas/app/model"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// WriteSKPIPDF renders the SKPI achievements section on portrait A4 pages.
+// Every heading and description is printed in Indonesian with the English
+// text in italics below it.
+func WriteSKPIPDF(w io.Writer, document *model.SKPIDocument) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+
+	status := "Draf - belum difinalisasi / Draft - not finalized"
+	if document.Finalized && document.FinalizedAt != nil {
+		status = fmt.Sprintf("Difinalisasi / Finalized %s", document.FinalizedAt.Format("02-01-2006"))
+	}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 7)
+		pdf.CellFormat(0, 5, status, "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("%d", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 7, translate(document.Institution), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 6, "SURAT KETERANGAN PENDAMPING IJAZAH", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "I", 10)
+	pdf.CellFormat(0, 5, "Diploma Supplement", "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+
+	student := document.Student
+	for _, field := range []struct{ label, value string }{
+		{"Nama / Name", student.FullName},
+		{"NIM / Student Number", student.StudentNumber},
+		{"Program Studi / Study Program", student.ProgramStudy},
+		{"Angkatan / Year of Entry", student.AcademicYear},
+	} {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(60, 6, field.label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, translate(": "+field.value), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 6, "Prestasi dan Penghargaan", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "I", 10)
+	pdf.CellFormat(0, 5, "Achievements and Awards", "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	if len(document.Categories) == 0 {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(0, 5, "Tidak ada prestasi terverifikasi. / No verified achievements.", "", "L", false)
+	}
+
+	for _, category := range document.Categories {
+		pdf.Ln(2)
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(0, 6, translate(fmt.Sprintf("%s / %s", category.Name.ID, category.Name.EN)), "B", 1, "L", false, 0, "")
+		pdf.Ln(1)
+
+		for i, item := range category.Items {
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.CellFormat(8, 5, fmt.Sprintf("%d.", i+1), "", 0, "L", false, 0, "")
+			pdf.MultiCell(0, 5, translate(item.Description.ID), "", "L", false)
+			pdf.SetX(pdf.GetX() + 8)
+			pdf.SetFont("Helvetica", "I", 9)
+			pdf.MultiCell(0, 5, translate(item.Description.EN), "", "L", false)
+			pdf.Ln(1)
+		}
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"projek_uas/app/model"
+)
+
+// unknownBucket groups achievements without a value for a breakdown
+const unknownBucket = "unknown"
+
+type bucketSet map[string]*model.StatisticsBucket
+
+func (b bucketSet) add(key, label string, points int) {
+	if key == "" {
+		key = unknownBucket
+	}
+	bucket, ok := b[key]
+	if !ok {
+		bucket = &model.StatisticsBucket{Key: key, Label: label}
+		b[key] = bucket
+	}
+	bucket.Count++
+	bucket.TotalPoints += points
+}
+
+// byKey lists the buckets in key order, for time based breakdowns
+func (b bucketSet) byKey() []model.StatisticsBucket {
+	buckets := make([]model.StatisticsBucket, 0, len(b))
+	for _, bucket := range b {
+		buckets = append(buckets, *bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Key < buckets[j].Key })
+	return buckets
+}
+
+// byCount lists the buckets with the most achievements first
+func (b bucketSet) byCount() []model.StatisticsBucket {
+	buckets := b.byKey()
+	sort.SliceStable(buckets, func(i, j int) bool { return buckets[i].Count > buckets[j].Count })
+	return buckets
+}
+
+// BuildStatistics aggregates verified achievements. Each row is matched with
+// its document by MongoDB id; rows whose document is missing are skipped.
+// The month and semester breakdowns use the event date, or the verification
+// date when the achievement has none; the period breakdown uses the assigned
+// academic period.
+func BuildStatistics(rows []*model.VerifiedAchievementRow, documents map[string]*model.Achievement) *model.AchievementStatistics {
+	byType, byMonth, bySemester := bucketSet{}, bucketSet{}, bucketSet{}
+	byLevel, byProgram, byCohort, byAdvisor := bucketSet{}, bucketSet{}, bucketSet{}, bucketSet{}
+	byPeriod := bucketSet{}
+
+	stats := &model.AchievementStatistics{}
+	for _, row := range rows {
+		achievement, ok := documents[row.MongoAchievementID]
+		if !ok {
+			continue
+		}
+
+		points := achievement.Points
+		stats.TotalVerified++
+		stats.TotalPoints += points
+
+		byType.add(achievement.AchievementType, "", points)
+		byLevel.add(achievement.Details.CompetitionLevel, "", points)
+		byProgram.add(stringValue(row.ProgramStudy), "", points)
+		byCohort.add(stringValue(row.AcademicYear), "", points)
+		byAdvisor.add(stringValue(row.AdvisorID), stringValue(row.AdvisorName), points)
+		byPeriod.add(stringValue(row.PeriodCode), stringValue(row.PeriodName), points)
+
+		var date *time.Time
+		if achievement.Details.EventDate != nil {
+			date = achievement.Details.EventDate
+		} else {
+			date = row.VerifiedAt
+		}
+		if date != nil {
+			byMonth.add(date.Format("2006-01"), "", points)
+			semester, label := AcademicSemester(*date)
+			bySemester.add(semester, label, points)
+		} else {
+			byMonth.add("", "", points)
+			bySemester.add("", "", points)
+		}
+	}
+
+	stats.ByType = byType.byCount()
+	stats.ByMonth = byMonth.byKey()
+	stats.BySemester = bySemester.byKey()
+	stats.ByCompetitionLevel = byLevel.byCount()
+	stats.ByProgramStudy = byProgram.byCount()
+	stats.ByCohort = byCohort.byKey()
+	stats.ByAdvisor = byAdvisor.byCount()
+	stats.ByPeriod = byPeriod.byKey()
+
+	return stats
+}
+
+// AcademicSemester returns the semester a date falls in, as a sortable key
+// and a label. The odd (ganjil) semester runs from August to January, the
+// even (genap) semester from February to July.
+func AcademicSemester(date time.Time) (string, string) {
+	year := date.Year()
+	switch {
+	case date.Month() >= time.August:
+		return fmt.Sprintf("%d-1", year), fmt.Sprintf("Ganjil %d/%d", year, year+1)
+	case date.Month() == time.January:
+		return fmt.Sprintf("%d-1", year-1), fmt.Sprintf("Ganjil %d/%d", year-1, year)
+	default:
+		return fmt.Sprintf("%d-2", year-1), fmt.Sprintf("Genap %d/%d", year-1, year)
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,19 @@
+package helper
+
+import "strings"
+
+// CleanTag trims a tag and collapses inner whitespace to single spaces
+func CleanTag(tag string) string {
+	return strings.Join(strings.Fields(tag), " ")
+}
+
+// TagKey is the form tags and synonyms are matched on, so "AI", " ai " and
+// "Ai" are the same tag
+func TagKey(tag string) string {
+	return strings.ToLower(CleanTag(tag))
+}
+
+// EscapeLike escapes the LIKE wildcards in a value matched literally
+func EscapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
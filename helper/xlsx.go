@@ -0,0 +1,189 @@
+package helper
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"projek_uas/app/model"
+)
+
+// The workbook parts that do not depend on the data. Cell style 1 formats
+// dates, style 2 is the bold header row.
+var xlsxStaticParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border/></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`},
+}
+
+// excelEpoch is day zero of spreadsheet date serials
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// writeXLSX writes a single sheet workbook. Rows are written to the zip
+// stream as they are emitted; numbers and dates become typed cells.
+func writeXLSX(w io.Writer, table ExportTable) error {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxStaticParts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+
+	workbook, err := archive.Create("xl/workbook.xml")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(workbook, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`, xmlEscape(sheetName(table.Title)))
+	if err != nil {
+		return err
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+
+	var row strings.Builder
+	row.WriteString(`<row r="1">`)
+	for i, column := range table.Columns {
+		fmt.Fprintf(&row, `<c r="%s1" t="inlineStr" s="2"><is><t>%s</t></is></c>`, columnName(i), xmlEscape(column.Header))
+	}
+	row.WriteString(`</row>`)
+	if _, err := io.WriteString(sheet, row.String()); err != nil {
+		return err
+	}
+
+	number := 1
+	err = table.Rows(func(values []interface{}) error {
+		number++
+		row.Reset()
+		fmt.Fprintf(&row, `<row r="%d">`, number)
+		for i, value := range values {
+			ref := columnName(i) + strconv.Itoa(number)
+			columnType := model.ExportColumnText
+			if i < len(table.Columns) {
+				columnType = table.Columns[i].Type
+			}
+			row.WriteString(xlsxCell(ref, columnType, value))
+		}
+		row.WriteString(`</row>`)
+		_, err := io.WriteString(sheet, row.String())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func xlsxCell(ref, columnType string, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	switch columnType {
+	case model.ExportColumnNumber:
+		switch v := value.(type) {
+		case int:
+			return fmt.Sprintf(`<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			return fmt.Sprintf(`<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	case model.ExportColumnDate:
+		var date *time.Time
+		switch v := value.(type) {
+		case time.Time:
+			date = &v
+		case *time.Time:
+			date = v
+		}
+		if date == nil {
+			return ""
+		}
+		local := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		serial := int(local.Sub(excelEpoch).Hours() / 24)
+		return fmt.Sprintf(`<c r="%s" s="1"><v>%d</v></c>`, ref, serial)
+	}
+
+	text := formatExportValue(value)
+	if text == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(text))
+}
+
+// columnName turns a zero based column index into its letters (0 is A, 26 is AA)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// sheetName fits a title within the 31 characters and character set allowed
+// for sheet names
+func sheetName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	if name == "" {
+		name = "Sheet1"
+	}
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	return name
+}
+
+func xmlEscape(value string) string {
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(value))
+	return builder.String()
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 	"projek_uas/config"
 )
 
@@ -11,6 +12,11 @@ func main() {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 
+	// Maintenance subcommands, e.g. "reconcile"
+	if len(os.Args) > 1 {
+		os.Exit(config.RunCommand(os.Args[1:]))
+	}
+
 	config.LogInfo("Starting Student Achievement System API")
 
 	// Load configuration
@@ -13,7 +13,19 @@ func Setup(
 	jwtSecret string,
 	authService *service.AuthService,
 	userRepo *repository.UserRepository,
-	achievementRepo *repository.AchievementRepository,
+	achievementService *service.AchievementService,
+	commentService *service.CommentService,
+	approvalService *service.ApprovalService,
+	notificationService *service.NotificationService,
+	slaService *service.SLAService,
+	skpiService *service.SKPIService,
+	certificateService *service.CertificateService,
+	credentialService *service.CredentialService,
+	retentionService *service.RetentionService,
+	certificationService *service.CertificationService,
+	tagService *service.TagService,
+	periodService *service.PeriodService,
+	graduationService *service.GraduationService,
 	studentRepo *repository.StudentRepository,
 	lecturerRepo *repository.LecturerRepository,
 ) {
@@ -24,6 +36,14 @@ func Setup(
 	auth.Post("/login", authService.HandleLoginHTTP)
 	auth.Post("/refresh", authService.HandleRefreshTokenHTTP)
 
+	// Public certificate verification, linked from the certificate's QR code
+	api.Get("/public/verify/:token", certificateService.HandleVerifyHTTP)
+
+	// Open Badges issuer profile, status list and offline credential check
+	api.Get("/public/issuer", credentialService.HandleIssuerProfileHTTP)
+	api.Get("/public/credentials/status", credentialService.HandleStatusListHTTP)
+	api.Post("/public/credentials/verify", credentialService.HandleVerifyCredentialHTTP)
+
 	// Protected routes
 	auth.Get("/profile", middleware.AuthMiddleware(jwtSecret), authService.HandleGetProfileHTTP)
 	auth.Post("/logout", middleware.AuthMiddleware(jwtSecret), authService.HandleLogoutHTTP)
@@ -40,31 +60,83 @@ func Setup(
 	users.Delete("/:id/permanent", userRepo.HandleHardDeleteHTTP)
 	users.Post("/:id/restore", userRepo.HandleRestoreHTTP)
 
+	// Approval chains (Admin only)
+	approvalChains := api.Group("/approval-chains", middleware.AuthMiddleware(jwtSecret), middleware.RequirePermission("user:manage"))
+	approvalChains.Get("/", approvalService.HandleGetChainsHTTP)
+	approvalChains.Post("/", approvalService.HandleCreateChainHTTP)
+	approvalChains.Delete("/:id", approvalService.HandleDeleteChainHTTP)
+
+	// Tag vocabulary, managed by admins
+	tags := api.Group("/tags", middleware.AuthMiddleware(jwtSecret))
+	tags.Get("/", tagService.HandleGetTagsHTTP)
+	tags.Get("/autocomplete", tagService.HandleAutocompleteHTTP)
+	tags.Get("/:id", tagService.HandleGetTagHTTP)
+	tags.Post("/", middleware.RequireRole("Admin"), tagService.HandleCreateTagHTTP)
+	tags.Put("/:id", middleware.RequireRole("Admin"), tagService.HandleUpdateTagHTTP)
+	tags.Delete("/:id", middleware.RequireRole("Admin"), tagService.HandleDeleteTagHTTP)
+
+	// Academic periods, readable by everyone for report filters
+	periods := api.Group("/academic-periods", middleware.AuthMiddleware(jwtSecret))
+	periods.Get("/", periodService.HandleGetPeriodsHTTP)
+	periods.Get("/active", periodService.HandleGetActivePeriodHTTP)
+	periods.Post("/", middleware.RequireRole("Admin"), periodService.HandleCreatePeriodHTTP)
+	periods.Put("/:id", middleware.RequireRole("Admin"), periodService.HandleUpdatePeriodHTTP)
+	periods.Delete("/:id", middleware.RequireRole("Admin"), periodService.HandleDeletePeriodHTTP)
+
 	// Achievements
 	achievements := api.Group("/achievements", middleware.AuthMiddleware(jwtSecret))
-	achievements.Get("/", func(c *fiber.Ctx) error {
-		return achievementRepo.HandleGetAllHTTP(c, studentRepo, lecturerRepo)
-	})
-	achievements.Get("/:id", func(c *fiber.Ctx) error {
-		return achievementRepo.HandleGetByIDHTTP(c, studentRepo, lecturerRepo)
-	})
-	achievements.Post("/", middleware.RequirePermission("achievement:create"), func(c *fiber.Ctx) error {
-		return achievementRepo.HandleCreateHTTP(c, studentRepo)
-	})
-	achievements.Put("/:id", middleware.RequirePermission("achievement:update"), func(c *fiber.Ctx) error {
-		return achievementRepo.HandleUpdateHTTP(c, studentRepo)
-	})
-	achievements.Delete("/:id", middleware.RequirePermission("achievement:delete"), func(c *fiber.Ctx) error {
-		return achievementRepo.HandleDeleteHTTP(c, studentRepo)
-	})
-	achievements.Post("/:id/submit", middleware.RequireRole("Mahasiswa"), func(c *fiber.Ctx) error {
-		return achievementRepo.HandleSubmitHTTP(c, studentRepo)
-	})
-	achievements.Post("/:id/verify", middleware.RequirePermission("achievement:verify"), achievementRepo.HandleVerifyHTTP)
+	achievements.Get("/", achievementService.HandleGetAllHTTP)
+	achievements.Get("/search", achievementService.HandleSearchHTTP)
+	achievements.Get("/deleted", middleware.RequireRole("Mahasiswa", "Admin"), retentionService.HandleListDeletedHTTP)
+	achievements.Post("/purge", middleware.RequireRole("Admin"), retentionService.HandlePurgeHTTP)
+	achievements.Get("/:id", achievementService.HandleGetByIDHTTP)
+	achievements.Get("/:id/certificate", certificateService.HandleGetCertificateHTTP)
+	achievements.Post("/:id/credential", credentialService.HandleIssueCredentialHTTP)
+	achievements.Get("/:id/history", achievementService.HandleGetStatusHistoryHTTP)
+	achievements.Get("/:id/revisions", achievementService.HandleGetRevisionsHTTP)
+	achievements.Get("/:id/revisions/diff", achievementService.HandleGetRevisionDiffHTTP)
+	achievements.Get("/:id/comments", commentService.HandleGetCommentsHTTP)
+	achievements.Post("/:id/comments", commentService.HandleCreateCommentHTTP)
+	achievements.Put("/:id/comments/:commentId", commentService.HandleUpdateCommentHTTP)
+	achievements.Delete("/:id/comments/:commentId", commentService.HandleDeleteCommentHTTP)
+	achievements.Post("/", middleware.RequirePermission("achievement:create"), achievementService.HandleCreateHTTP)
+	achievements.Post("/bulk-verify", middleware.RequirePermission("achievement:verify"), achievementService.HandleBulkVerifyHTTP)
+	achievements.Put("/:id", middleware.RequirePermission("achievement:update"), achievementService.HandleUpdateHTTP)
+	achievements.Delete("/:id", middleware.RequirePermission("achievement:delete"), achievementService.HandleDeleteHTTP)
+	achievements.Post("/:id/restore", middleware.RequireRole("Mahasiswa", "Admin"), retentionService.HandleRestoreHTTP)
+	achievements.Post("/:id/submit", middleware.RequireRole("Mahasiswa"), achievementService.HandleSubmitHTTP)
+	achievements.Post("/:id/withdraw", middleware.RequireRole("Mahasiswa"), achievementService.HandleWithdrawHTTP)
+	achievements.Post("/:id/members/confirm", middleware.RequireRole("Mahasiswa"), achievementService.HandleConfirmMembershipHTTP)
+	achievements.Post("/:id/members/decline", middleware.RequireRole("Mahasiswa"), achievementService.HandleDeclineMembershipHTTP)
+	achievements.Post("/:id/verify", middleware.RequirePermission("achievement:verify"), achievementService.HandleVerifyHTTP)
+	achievements.Post("/:id/revoke", middleware.RequireRole("Admin"), achievementService.HandleRevokeHTTP)
+	achievements.Put("/:id/duplicate-of", middleware.RequirePermission("achievement:verify"), achievementService.HandleSetDuplicateOfHTTP)
+	achievements.Put("/:id/renewal-of", middleware.RequireRole("Mahasiswa"), certificationService.HandleSetRenewalOfHTTP)
 
 	// Reports
 	reports := api.Group("/reports", middleware.AuthMiddleware(jwtSecret))
-	reports.Get("/statistics", func(c *fiber.Ctx) error {
-		return achievementRepo.HandleStatisticsHTTP(c, studentRepo, lecturerRepo)
-	})
+	reports.Get("/statistics", achievementService.HandleStatisticsHTTP)
+	reports.Get("/leaderboard", achievementService.HandleLeaderboardHTTP)
+	reports.Get("/review-latency", middleware.RequirePermission("report:view"), slaService.HandleReviewLatencyHTTP)
+
+	// Graduation requirements (SKKM) and progress towards them
+	graduation := api.Group("/graduation", middleware.AuthMiddleware(jwtSecret))
+	graduation.Get("/requirements", graduationService.HandleGetRequirementsHTTP)
+	graduation.Post("/requirements", middleware.RequireRole("Admin"), graduationService.HandleCreateRequirementHTTP)
+	graduation.Put("/requirements/:id", middleware.RequireRole("Admin"), graduationService.HandleUpdateRequirementHTTP)
+	graduation.Delete("/requirements/:id", middleware.RequireRole("Admin"), graduationService.HandleDeleteRequirementHTTP)
+	graduation.Get("/progress", middleware.RequireRole("Mahasiswa"), graduationService.HandleGetOwnProgressHTTP)
+	graduation.Get("/progress/:studentId", graduationService.HandleGetProgressHTTP)
+	graduation.Get("/at-risk", middleware.RequireRole("Dosen Wali", "Admin", "Kaprodi"), graduationService.HandleGetAtRiskHTTP)
+
+	// SKPI achievements section
+	skpi := api.Group("/skpi", middleware.AuthMiddleware(jwtSecret))
+	skpi.Get("/:studentId", skpiService.HandleGetSKPIHTTP)
+	skpi.Post("/:studentId/finalize", middleware.RequireRole("Admin"), skpiService.HandleFinalizeSKPIHTTP)
+
+	// Notifications
+	notifications := api.Group("/notifications", middleware.AuthMiddleware(jwtSecret))
+	notifications.Get("/", notificationService.HandleGetNotificationsHTTP)
+	notifications.Post("/read-all", notificationService.HandleMarkAllReadHTTP)
+	notifications.Post("/:id/read", notificationService.HandleMarkReadHTTP)
 }